@@ -27,19 +27,19 @@ var (
 )
 
 // ChannelABI is the input ABI used to generate the binding from.
-const ChannelABI = "[{\"inputs\":[{\"internalType\":\"addresspayable\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"timeout\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"}],\"name\":\"AlterOwner\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"closeChannel\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"ChannelTimeout\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"hash\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"sign\",\"type\":\"bytes\"}],\"name\":\"CloseChannel\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"alterOwner\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"addTime\",\"type\":\"uint256\"}],\"name\":\"extend\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getInfo\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getOwner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]"
+const ChannelABI = "[{\"inputs\":[{\"internalType\":\"addresspayable\",\"name\":\"sender\",\"type\":\"address\"},{\"internalType\":\"address[]\",\"name\":\"to\",\"type\":\"address[]\"},{\"internalType\":\"uint256\",\"name\":\"timeout\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"channelFactory\",\"type\":\"address\"}],\"stateMutability\":\"payable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"}],\"name\":\"AlterOwner\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"channelPay\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"closeChannel\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"ChannelTimeout\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"hash\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"sign\",\"type\":\"bytes\"}],\"name\":\"DemandPayment\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"addTime\",\"type\":\"uint256\"}],\"name\":\"Extend\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"GetFactory\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"GetInfo\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"address[]\",\"name\":\"\",\"type\":\"address[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"}],\"name\":\"GetNonceValue\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"GetVersion\",\"outputs\":[{\"internalType\":\"uint16\",\"name\":\"\",\"type\":\"uint16\"}],\"stateMutability\":\"pure\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"}],\"name\":\"GetWithdrawn\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"hash\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"total\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"sign\",\"type\":\"bytes\"}],\"name\":\"SettlePayment\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"alterOwner\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getOwner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]"
 
 // ChannelBin is the compiled bytecode used for deploying new contracts.
-var ChannelBin = "0x6080604052738026796fd7ce63eae824314aa5bacf55643e893d600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550735ef5fd065210cbd62dfe86cc68a0e1ab2c5cba50600660006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604051610f30380380610f30833981810160405260408110156100d057600080fd5b810190808051906020019092919080519060200190929190505050336000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055506000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1663de60908a6040518163ffffffff1660e01b815260040160206040518083038186803b15801561019557600080fd5b505afa1580156101a9573d6000803e3d6000fd5b505050506040513d60208110156101bf57600080fd5b81019080805190602001909291905050509050600161ffff168161ffff1610610250576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260188152602001807f6465706c6f79206368616e6e656c2069732062616e6e6564000000000000000081525060200191505060405180910390fd5b6000821161025d57600080fd5b82600260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555033600160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055504260038190555081600481905550505050610c31806102ff6000396000f3fe6080604052600436106100595760003560e01c80630ca05f9f146100655780632b7fa6be146100cc578063396582451461019b5780635a9b0b89146101b2578063893d20e81461021e5780639714378c1461025f57610060565b3661006057005b600080fd5b34801561007157600080fd5b506100b46004803603602081101561008857600080fd5b81019080803573ffffffffffffffffffffffffffffffffffffffff16906020019092919050505061029a565b60405180821515815260200191505060405180910390f35b610199600480360360608110156100e257600080fd5b8101908080359060200190929190803590602001909291908035906020019064010000000081111561011357600080fd5b82018360208201111561012557600080fd5b8035906020019184600183028401116401000000008311171561014757600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600081840152601f19601f820116905080830192505050505050509192919290505050610439565b005b3480156101a757600080fd5b506101b0610891565b005b3480156101be57600080fd5b506101c761095c565b604051808581526020018481526020018373ffffffffffffffffffffffffffffffffffffffff1681526020018273ffffffffffffffffffffffffffffffffffffffff16815260200194505050505060405180910390f35b34801561022a57600080fd5b506102336109bc565b604051808273ffffffffffffffffffffffffffffffffffffffff16815260200191505060405180910390f35b34801561026b57600080fd5b506102986004803603602081101561028257600080fd5b81019080803590602001909291905050506109e5565b005b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff161461035e576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260138152602001807f6f6e6c79206f776e65722063616e2063616c6c0000000000000000000000000081525060200191505060405180910390fd5b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050826000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055507f8c153ecee6895f15da72e646b4029e0ef7cbf971986d8d9cfe48c5563d368e908184604051808373ffffffffffffffffffffffffffffffffffffffff1681526020018273ffffffffffffffffffffffffffffffffffffffff1681526020019250505060405180910390a16001915050919050565b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16146104fc576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252600e8152602001807f696c6c6567616c2063616c6c657200000000000000000000000000000000000081525060200191505060405180910390fd5b60003083604051602001808373ffffffffffffffffffffffffffffffffffffffff1660601b8152601401828152602001925050506040516020818303038152906040528051906020012090508381146105bd576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252600c8152602001807f696c6c6567616c2068617368000000000000000000000000000000000000000081525060200191505060405180910390fd5b6000600660009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166319045a2586856040518363ffffffff1660e01b81526004018083815260200180602001828103825283818151815260200191508051906020019080838360005b83811015610654578082015181840152602081019050610639565b50505050905090810190601f1680156106815780820380516001836020036101000a031916815260200191505b50935050505060206040518083038186803b15801561069f57600080fd5b505afa1580156106b3573d6000803e3d6000fd5b505050506040513d60208110156106c957600080fd5b81019080805190602001909291905050509050600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff161461079f576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252600b8152602001807f696c6c6567616c2073696700000000000000000000000000000000000000000081525060200191505060405180910390fd5b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166108fc859081150290604051600060405180830381858888f19350505050158015610807573d6000803e3d6000fd5b503373ffffffffffffffffffffffffffffffffffffffff167f01d42a9c1bb0e1a3464994bd2306368ef80e0dcf460c6123b5f7cbbcbf169fbb856040518082815260200191505060405180910390a2600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16ff5b60035460045460035401116108a557600080fd5b42600454600354011115610921576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040180806020018281038252600e8152602001807f54696d65206973206e6f7420757000000000000000000000000000000000000081525060200191505060405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16ff5b600080600080600354600454600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16935093509350935090919293565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60008054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610aa6576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260138152602001807f6f6e6c79206f776e65722063616e2063616c6c0000000000000000000000000081525060200191505060405180910390fd5b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1663de60908a6040518163ffffffff1660e01b815260040160206040518083038186803b158015610b1057600080fd5b505afa158015610b24573d6000803e3d6000fd5b505050506040513d6020811015610b3a57600080fd5b81019080805190602001909291905050509050600161ffff168161ffff1610610bcb576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004018080602001828103825260108152602001807f657874656e642069732062616e6e65640000000000000000000000000000000081525060200191505060405180910390fd5b60008211610bd857600080fd5b6000826004540190506004548111610bef57600080fd5b8060048190555050505056fea2646970667358221220b5079c4662b25baba3913017f3d378e52aeba078e611c7b26b459d8bd36f03b564736f6c63430007030033"
+var ChannelBin = "0x60a0604052738026796fd7ce63eae824314aa5bacf55643e893d600760006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604051620025203803806200252083398181016040528101906200007e91906200061d565b336000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055503373ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff1614806200012457508073ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16145b62000166576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016200015d906200070f565b60405180910390fd5b6000600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1663de60908a6040518163ffffffff1660e01b8152600401602060405180830381865afa158015620001d6573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190620001fc919062000770565b9050600261ffff168161ffff16106200024c576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016200024390620007f2565b60405180910390fd5b600083116200025a57600080fd5b83600290805190602001906200027292919062000301565b5084600160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555042600581905550826006819055508173ffffffffffffffffffffffffffffffffffffffff1660808173ffffffffffffffffffffffffffffffffffffffff1681525050505050505062000814565b8280548282559060005260206000209081019282156200037d579160200282015b828111156200037c5782518260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055509160200191906001019062000322565b5b5090506200038c919062000390565b5090565b5b80821115620003ab57600081600090555060010162000391565b5090565b6000604051905090565b600080fd5b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620003f082620003c3565b9050919050565b6200040281620003e3565b81146200040e57600080fd5b50565b6000815190506200042281620003f7565b92915050565b600080fd5b6000601f19601f8301169050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b62000478826200042d565b810181811067ffffffffffffffff821117156200049a57620004996200043e565b5b80604052505050565b6000620004af620003af565b9050620004bd82826200046d565b919050565b600067ffffffffffffffff821115620004e057620004df6200043e565b5b602082029050602081019050919050565b600080fd5b60006200050382620003c3565b9050919050565b6200051581620004f6565b81146200052157600080fd5b50565b60008151905062000535816200050a565b92915050565b6000620005526200054c84620004c2565b620004a3565b90508083825260208201905060208402830185811115620005785762000577620004f1565b5b835b81811015620005a5578062000590888262000524565b8452602084019350506020810190506200057a565b5050509392505050565b600082601f830112620005c757620005c662000428565b5b8151620005d98482602086016200053b565b91505092915050565b6000819050919050565b620005f781620005e2565b81146200060357600080fd5b50565b6000815190506200061781620005ec565b92915050565b600080600080608085870312156200063a5762000639620003b9565b5b60006200064a8782880162000411565b945050602085015167ffffffffffffffff8111156200066e576200066d620003be565b5b6200067c87828801620005af565b93505060406200068f8782880162000606565b9250506060620006a28782880162000524565b91505092959194509250565b600082825260208201905092915050565b7f696c6c6567616c2073656e646572000000000000000000000000000000000000600082015250565b6000620006f7600e83620006ae565b91506200070482620006bf565b602082019050919050565b600060208201905081810360008301526200072a81620006e8565b9050919050565b600061ffff82169050919050565b6200074a8162000731565b81146200075657600080fd5b50565b6000815190506200076a816200073f565b92915050565b600060208284031215620007895762000788620003b9565b5b6000620007998482850162000759565b91505092915050565b7f6465706c6f79206368616e6e656c2069732062616e6e65640000000000000000600082015250565b6000620007da601883620006ae565b9150620007e782620007a2565b602082019050919050565b600060208201905081810360008301526200080d81620007cb565b9050919050565b608051611cf062000830600039600061057c0152611cf06000f3fe6080604052600436106100a05760003560e01c80638418842a116100645780638418842a14610191578063893d20e8146101bf578063964ae133146101ea578063c328cd3214610227578063c6129a5a14610250578063f6b19d521461027b576100a7565b806302ef6561146100ac5780630ca05f9f146100d557806312f7f44814610112578063396582451461013d578063771d26e014610154576100a7565b366100a757005b600080fd5b3480156100b857600080fd5b506100d360048036038101906100ce91906110ee565b610297565b005b3480156100e157600080fd5b506100fc60048036038101906100f79190611179565b61043e565b60405161010991906111c1565b60405180910390f35b34801561011e57600080fd5b50610127610578565b60405161013491906111eb565b60405180910390f35b34801561014957600080fd5b506101526105a0565b005b34801561016057600080fd5b5061017b60048036038101906101769190611206565b6106ba565b60405161018891906111c1565b60405180910390f35b34801561019d57600080fd5b506101a6610722565b6040516101b69493929190611313565b60405180910390f35b3480156101cb57600080fd5b506101d46107ea565b6040516101e191906111eb565b60405180910390f35b3480156101f657600080fd5b50610211600480360381019061020c9190611179565b610813565b60405161021e919061135f565b60405180910390f35b34801561023357600080fd5b5061024e600480360381019061024991906114f6565b61085c565b005b34801561025c57600080fd5b50610265610ba6565b6040516102729190611582565b60405180910390f35b6102956004803603810190610290919061159d565b610baf565b005b60008054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff1614610325576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161031c9061167d565b60405180910390fd5b6000600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1663de60908a6040518163ffffffff1660e01b8152600401602060405180830381865afa158015610394573d6000803e3d6000fd5b505050506040513d601f19601f820116820180604052508101906103b891906116c9565b9050600261ffff168161ffff1610610405576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016103fc90611742565b60405180910390fd5b6000821161041257600080fd5b6000826006546104229190611791565b9050600654811161043257600080fd5b80600681905550505050565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16146104cf576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104c69061167d565b60405180910390fd5b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050826000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055507f8c153ecee6895f15da72e646b4029e0ef7cbf971986d8d9cfe48c5563d368e9081846040516105669291906117c5565b60405180910390a16001915050919050565b60007f0000000000000000000000000000000000000000000000000000000000000000905090565b6005546006546005546105b39190611791565b116105bd57600080fd5b426006546005546105ce9190611791565b111561060f576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016106069061183a565b60405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff167f01d42a9c1bb0e1a3464994bd2306368ef80e0dcf460c6123b5f7cbbcbf169fbb47604051610677919061135f565b60405180910390a2600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16ff5b6000600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600083815260200190815260200160002060009054906101000a900460ff16905092915050565b60008060006060600554600654600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff166002808054806020026020016040519081016040528092919081815260200182805480156107d557602002820191906000526020600020905b8160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001906001019080831161078b575b50505050509050935093509350935090919293565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b6000600460008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b61086533610eee565b6108a4576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161089b906118a6565b60405180910390fd5b600460003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020548211610925576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161091c90611912565b60405180910390fd5b600030833360405160200161093c9392919061199b565b604051602081830303815290604052805190602001209050838114610996576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161098d90611a24565b60405180910390fd5b60006109ab8386610f9c90919063ffffffff16565b9050600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1614610a3d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610a3490611a90565b60405180910390fd5b6000600460003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000205485610a8a9190611ab0565b905084600460003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020819055503373ffffffffffffffffffffffffffffffffffffffff166108fc829081150290604051600060405180830381858888f19350505050158015610b16573d6000803e3d6000fd5b503373ffffffffffffffffffffffffffffffffffffffff16600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff167f5f8385d57977d2bf0444ccd54a1135dba3f6e45556c5164e3f4228cf7b3db2a583604051610b96919061135f565b60405180910390a3505050505050565b60006002905090565b610bb833610eee565b610bf7576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610bee906118a6565b60405180910390fd5b600360003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600083815260200190815260200160002060009054906101000a900460ff1615610c95576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610c8c90611b30565b60405180910390fd5b600030848433604051602001610cae9493929190611b50565b604051602081830303815290604052805190602001209050848114610d08576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610cff90611a24565b60405180910390fd5b6000610d1d8387610f9c90919063ffffffff16565b9050600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1614610daf576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610da690611a90565b60405180910390fd5b6001600360003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600086815260200190815260200160002060006101000a81548160ff0219169083151502179055503373ffffffffffffffffffffffffffffffffffffffff166108fc869081150290604051600060405180830381858888f19350505050158015610e5e573d6000803e3d6000fd5b503373ffffffffffffffffffffffffffffffffffffffff16600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff167f5f8385d57977d2bf0444ccd54a1135dba3f6e45556c5164e3f4228cf7b3db2a587604051610ede919061135f565b60405180910390a3505050505050565b600080600090505b600280549050811015610f91578273ffffffffffffffffffffffffffffffffffffffff1660028281548110610f2e57610f2d611b9e565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1603610f7e576001915050610f97565b8080610f8990611bcd565b915050610ef6565b50600090505b919050565b60006041825114610fb0576000905061109e565b60008060006020850151925060408501519150606085015160001a90507f7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a08260001c1115611004576000935050505061109e565b601b8160ff16101561102057601b8161101d9190611c22565b90505b601b8160ff16141580156110385750601c8160ff1614155b15611049576000935050505061109e565b6001868285856040516000815260200160405260405161106c9493929190611c75565b6020604051602081039080840390855afa15801561108e573d6000803e3d6000fd5b5050506020604051035193505050505b92915050565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b6110cb816110b8565b81146110d657600080fd5b50565b6000813590506110e8816110c2565b92915050565b600060208284031215611104576111036110ae565b5b6000611112848285016110d9565b91505092915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b60006111468261111b565b9050919050565b6111568161113b565b811461116157600080fd5b50565b6000813590506111738161114d565b92915050565b60006020828403121561118f5761118e6110ae565b5b600061119d84828501611164565b91505092915050565b60008115159050919050565b6111bb816111a6565b82525050565b60006020820190506111d660008301846111b2565b92915050565b6111e58161113b565b82525050565b600060208201905061120060008301846111dc565b92915050565b6000806040838503121561121d5761121c6110ae565b5b600061122b85828601611164565b925050602061123c858286016110d9565b9150509250929050565b61124f816110b8565b82525050565b600081519050919050565b600082825260208201905092915050565b6000819050602082019050919050565b61128a8161113b565b82525050565b600061129c8383611281565b60208301905092915050565b6000602082019050919050565b60006112c082611255565b6112ca8185611260565b93506112d583611271565b8060005b838110156113065781516112ed8882611290565b97506112f8836112a8565b9250506001810190506112d9565b5085935050505092915050565b60006080820190506113286000830187611246565b6113356020830186611246565b61134260408301856111dc565b818103606083015261135481846112b5565b905095945050505050565b60006020820190506113746000830184611246565b92915050565b6000819050919050565b61138d8161137a565b811461139857600080fd5b50565b6000813590506113aa81611384565b92915050565b600080fd5b600080fd5b6000601f19601f8301169050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b611403826113ba565b810181811067ffffffffffffffff82111715611422576114216113cb565b5b80604052505050565b60006114356110a4565b905061144182826113fa565b919050565b600067ffffffffffffffff821115611461576114606113cb565b5b61146a826113ba565b9050602081019050919050565b82818337600083830152505050565b600061149961149484611446565b61142b565b9050828152602081018484840111156114b5576114b46113b5565b5b6114c0848285611477565b509392505050565b600082601f8301126114dd576114dc6113b0565b5b81356114ed848260208601611486565b91505092915050565b60008060006060848603121561150f5761150e6110ae565b5b600061151d8682870161139b565b935050602061152e868287016110d9565b925050604084013567ffffffffffffffff81111561154f5761154e6110b3565b5b61155b868287016114c8565b9150509250925092565b600061ffff82169050919050565b61157c81611565565b82525050565b60006020820190506115976000830184611573565b92915050565b600080600080608085870312156115b7576115b66110ae565b5b60006115c58782880161139b565b94505060206115d6878288016110d9565b93505060406115e7878288016110d9565b925050606085013567ffffffffffffffff811115611608576116076110b3565b5b611614878288016114c8565b91505092959194509250565b600082825260208201905092915050565b7f6f6e6c79206f776e65722063616e2063616c6c00000000000000000000000000600082015250565b6000611667601383611620565b915061167282611631565b602082019050919050565b600060208201905081810360008301526116968161165a565b9050919050565b6116a681611565565b81146116b157600080fd5b50565b6000815190506116c38161169d565b92915050565b6000602082840312156116df576116de6110ae565b5b60006116ed848285016116b4565b91505092915050565b7f657874656e642069732062616e6e656400000000000000000000000000000000600082015250565b600061172c601083611620565b9150611737826116f6565b602082019050919050565b6000602082019050818103600083015261175b8161171f565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061179c826110b8565b91506117a7836110b8565b92508282019050808211156117bf576117be611762565b5b92915050565b60006040820190506117da60008301856111dc565b6117e760208301846111dc565b9392505050565b7f54696d65206973206e6f74207570000000000000000000000000000000000000600082015250565b6000611824600e83611620565b915061182f826117ee565b602082019050919050565b6000602082019050818103600083015261185381611817565b9050919050565b7f696c6c6567616c2063616c6c6572000000000000000000000000000000000000600082015250565b6000611890600e83611620565b915061189b8261185a565b602082019050919050565b600060208201905081810360008301526118bf81611883565b9050919050565b7f696c6c6567616c20746f74616c00000000000000000000000000000000000000600082015250565b60006118fc600d83611620565b9150611907826118c6565b602082019050919050565b6000602082019050818103600083015261192b816118ef565b9050919050565b60008160601b9050919050565b600061194a82611932565b9050919050565b600061195c8261193f565b9050919050565b61197461196f8261113b565b611951565b82525050565b6000819050919050565b611995611990826110b8565b61197a565b82525050565b60006119a78286611963565b6014820191506119b78285611984565b6020820191506119c78284611963565b601482019150819050949350505050565b7f696c6c6567616c20686173680000000000000000000000000000000000000000600082015250565b6000611a0e600c83611620565b9150611a19826119d8565b602082019050919050565b60006020820190508181036000830152611a3d81611a01565b9050919050565b7f696c6c6567616c20736967000000000000000000000000000000000000000000600082015250565b6000611a7a600b83611620565b9150611a8582611a44565b602082019050919050565b60006020820190508181036000830152611aa981611a6d565b9050919050565b6000611abb826110b8565b9150611ac6836110b8565b9250828203905081811115611ade57611add611762565b5b92915050565b7f696c6c6567616c206e6f6e636500000000000000000000000000000000000000600082015250565b6000611b1a600d83611620565b9150611b2582611ae4565b602082019050919050565b60006020820190508181036000830152611b4981611b0d565b9050919050565b6000611b5c8287611963565b601482019150611b6c8286611984565b602082019150611b7c8285611984565b602082019150611b8c8284611963565b60148201915081905095945050505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b6000611bd8826110b8565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8203611c0a57611c09611762565b5b600182019050919050565b600060ff82169050919050565b6000611c2d82611c15565b9150611c3883611c15565b9250828201905060ff811115611c5157611c50611762565b5b92915050565b611c608161137a565b82525050565b611c6f81611c15565b82525050565b6000608082019050611c8a6000830187611c57565b611c976020830186611c66565b611ca46040830185611c57565b611cb16060830184611c57565b9594505050505056fea2646970667358221220a7855748040827bb18851b1ba776d417cb1a9bcfa06cb14e12433cd1f778ba8564736f6c63430008150033"
 
 // DeployChannel deploys a new Ethereum contract, binding an instance of Channel to it.
-func DeployChannel(auth *bind.TransactOpts, backend bind.ContractBackend, to common.Address, timeout *big.Int) (common.Address, *types.Transaction, *Channel, error) {
+func DeployChannel(auth *bind.TransactOpts, backend bind.ContractBackend, sender common.Address, to []common.Address, timeout *big.Int, channelFactory common.Address) (common.Address, *types.Transaction, *Channel, error) {
 	parsed, err := abi.JSON(strings.NewReader(ChannelABI))
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(ChannelBin), backend, to, timeout)
+	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(ChannelBin), backend, sender, to, timeout, channelFactory)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -188,15 +188,41 @@ func (_Channel *ChannelTransactorRaw) Transact(opts *bind.TransactOpts, method s
 	return _Channel.Contract.contract.Transact(opts, method, params...)
 }
 
-// GetInfo is a free data retrieval call binding the contract method 0x5a9b0b89.
+// GetFactory is a free data retrieval call binding the contract method 0x12f7f448.
 //
-// Solidity: function getInfo() view returns(uint256, uint256, address, address)
-func (_Channel *ChannelCaller) GetInfo(opts *bind.CallOpts) (*big.Int, *big.Int, common.Address, common.Address, error) {
+// Solidity: function GetFactory() view returns(address)
+func (_Channel *ChannelCaller) GetFactory(opts *bind.CallOpts) (common.Address, error) {
+	var (
+		ret0 = new(common.Address)
+	)
+	out := ret0
+	err := _Channel.contract.Call(opts, out, "GetFactory")
+	return *ret0, err
+}
+
+// GetFactory is a free data retrieval call binding the contract method 0x12f7f448.
+//
+// Solidity: function GetFactory() view returns(address)
+func (_Channel *ChannelSession) GetFactory() (common.Address, error) {
+	return _Channel.Contract.GetFactory(&_Channel.CallOpts)
+}
+
+// GetFactory is a free data retrieval call binding the contract method 0x12f7f448.
+//
+// Solidity: function GetFactory() view returns(address)
+func (_Channel *ChannelCallerSession) GetFactory() (common.Address, error) {
+	return _Channel.Contract.GetFactory(&_Channel.CallOpts)
+}
+
+// GetInfo is a free data retrieval call binding the contract method 0x8418842a.
+//
+// Solidity: function GetInfo() view returns(uint256, uint256, address, address[])
+func (_Channel *ChannelCaller) GetInfo(opts *bind.CallOpts) (*big.Int, *big.Int, common.Address, []common.Address, error) {
 	var (
 		ret0 = new(*big.Int)
 		ret1 = new(*big.Int)
 		ret2 = new(common.Address)
-		ret3 = new(common.Address)
+		ret3 = new([]common.Address)
 	)
 	out := &[]interface{}{
 		ret0,
@@ -204,24 +230,102 @@ func (_Channel *ChannelCaller) GetInfo(opts *bind.CallOpts) (*big.Int, *big.Int,
 		ret2,
 		ret3,
 	}
-	err := _Channel.contract.Call(opts, out, "getInfo")
+	err := _Channel.contract.Call(opts, out, "GetInfo")
 	return *ret0, *ret1, *ret2, *ret3, err
 }
 
-// GetInfo is a free data retrieval call binding the contract method 0x5a9b0b89.
+// GetInfo is a free data retrieval call binding the contract method 0x8418842a.
 //
-// Solidity: function getInfo() view returns(uint256, uint256, address, address)
-func (_Channel *ChannelSession) GetInfo() (*big.Int, *big.Int, common.Address, common.Address, error) {
+// Solidity: function GetInfo() view returns(uint256, uint256, address, address[])
+func (_Channel *ChannelSession) GetInfo() (*big.Int, *big.Int, common.Address, []common.Address, error) {
 	return _Channel.Contract.GetInfo(&_Channel.CallOpts)
 }
 
-// GetInfo is a free data retrieval call binding the contract method 0x5a9b0b89.
+// GetInfo is a free data retrieval call binding the contract method 0x8418842a.
 //
-// Solidity: function getInfo() view returns(uint256, uint256, address, address)
-func (_Channel *ChannelCallerSession) GetInfo() (*big.Int, *big.Int, common.Address, common.Address, error) {
+// Solidity: function GetInfo() view returns(uint256, uint256, address, address[])
+func (_Channel *ChannelCallerSession) GetInfo() (*big.Int, *big.Int, common.Address, []common.Address, error) {
 	return _Channel.Contract.GetInfo(&_Channel.CallOpts)
 }
 
+// GetNonceValue is a free data retrieval call binding the contract method 0x771d26e0.
+//
+// Solidity: function GetNonceValue(address recipient, uint256 nonce) view returns(bool)
+func (_Channel *ChannelCaller) GetNonceValue(opts *bind.CallOpts, recipient common.Address, nonce *big.Int) (bool, error) {
+	var (
+		ret0 = new(bool)
+	)
+	out := ret0
+	err := _Channel.contract.Call(opts, out, "GetNonceValue", recipient, nonce)
+	return *ret0, err
+}
+
+// GetNonceValue is a free data retrieval call binding the contract method 0x771d26e0.
+//
+// Solidity: function GetNonceValue(address recipient, uint256 nonce) view returns(bool)
+func (_Channel *ChannelSession) GetNonceValue(recipient common.Address, nonce *big.Int) (bool, error) {
+	return _Channel.Contract.GetNonceValue(&_Channel.CallOpts, recipient, nonce)
+}
+
+// GetNonceValue is a free data retrieval call binding the contract method 0x771d26e0.
+//
+// Solidity: function GetNonceValue(address recipient, uint256 nonce) view returns(bool)
+func (_Channel *ChannelCallerSession) GetNonceValue(recipient common.Address, nonce *big.Int) (bool, error) {
+	return _Channel.Contract.GetNonceValue(&_Channel.CallOpts, recipient, nonce)
+}
+
+// GetVersion is a free data retrieval call binding the contract method 0xc6129a5a.
+//
+// Solidity: function GetVersion() pure returns(uint16)
+func (_Channel *ChannelCaller) GetVersion(opts *bind.CallOpts) (uint16, error) {
+	var (
+		ret0 = new(uint16)
+	)
+	out := ret0
+	err := _Channel.contract.Call(opts, out, "GetVersion")
+	return *ret0, err
+}
+
+// GetVersion is a free data retrieval call binding the contract method 0xc6129a5a.
+//
+// Solidity: function GetVersion() pure returns(uint16)
+func (_Channel *ChannelSession) GetVersion() (uint16, error) {
+	return _Channel.Contract.GetVersion(&_Channel.CallOpts)
+}
+
+// GetVersion is a free data retrieval call binding the contract method 0xc6129a5a.
+//
+// Solidity: function GetVersion() pure returns(uint16)
+func (_Channel *ChannelCallerSession) GetVersion() (uint16, error) {
+	return _Channel.Contract.GetVersion(&_Channel.CallOpts)
+}
+
+// GetWithdrawn is a free data retrieval call binding the contract method 0x964ae133.
+//
+// Solidity: function GetWithdrawn(address recipient) view returns(uint256)
+func (_Channel *ChannelCaller) GetWithdrawn(opts *bind.CallOpts, recipient common.Address) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _Channel.contract.Call(opts, out, "GetWithdrawn", recipient)
+	return *ret0, err
+}
+
+// GetWithdrawn is a free data retrieval call binding the contract method 0x964ae133.
+//
+// Solidity: function GetWithdrawn(address recipient) view returns(uint256)
+func (_Channel *ChannelSession) GetWithdrawn(recipient common.Address) (*big.Int, error) {
+	return _Channel.Contract.GetWithdrawn(&_Channel.CallOpts, recipient)
+}
+
+// GetWithdrawn is a free data retrieval call binding the contract method 0x964ae133.
+//
+// Solidity: function GetWithdrawn(address recipient) view returns(uint256)
+func (_Channel *ChannelCallerSession) GetWithdrawn(recipient common.Address) (*big.Int, error) {
+	return _Channel.Contract.GetWithdrawn(&_Channel.CallOpts, recipient)
+}
+
 // GetOwner is a free data retrieval call binding the contract method 0x893d20e8.
 //
 // Solidity: function getOwner() view returns(address)
@@ -269,25 +373,67 @@ func (_Channel *ChannelTransactorSession) ChannelTimeout() (*types.Transaction,
 	return _Channel.Contract.ChannelTimeout(&_Channel.TransactOpts)
 }
 
-// CloseChannel is a paid mutator transaction binding the contract method 0x2b7fa6be.
+// DemandPayment is a paid mutator transaction binding the contract method 0xf6b19d52.
+//
+// Solidity: function DemandPayment(bytes32 hash, uint256 value, uint256 nonce, bytes sign) payable returns()
+func (_Channel *ChannelTransactor) DemandPayment(opts *bind.TransactOpts, hash [32]byte, value *big.Int, nonce *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.contract.Transact(opts, "DemandPayment", hash, value, nonce, sign)
+}
+
+// DemandPayment is a paid mutator transaction binding the contract method 0xf6b19d52.
+//
+// Solidity: function DemandPayment(bytes32 hash, uint256 value, uint256 nonce, bytes sign) payable returns()
+func (_Channel *ChannelSession) DemandPayment(hash [32]byte, value *big.Int, nonce *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.Contract.DemandPayment(&_Channel.TransactOpts, hash, value, nonce, sign)
+}
+
+// DemandPayment is a paid mutator transaction binding the contract method 0xf6b19d52.
+//
+// Solidity: function DemandPayment(bytes32 hash, uint256 value, uint256 nonce, bytes sign) payable returns()
+func (_Channel *ChannelTransactorSession) DemandPayment(hash [32]byte, value *big.Int, nonce *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.Contract.DemandPayment(&_Channel.TransactOpts, hash, value, nonce, sign)
+}
+
+// Extend is a paid mutator transaction binding the contract method 0x02ef6561.
+//
+// Solidity: function Extend(uint256 addTime) returns()
+func (_Channel *ChannelTransactor) Extend(opts *bind.TransactOpts, addTime *big.Int) (*types.Transaction, error) {
+	return _Channel.contract.Transact(opts, "Extend", addTime)
+}
+
+// Extend is a paid mutator transaction binding the contract method 0x02ef6561.
+//
+// Solidity: function Extend(uint256 addTime) returns()
+func (_Channel *ChannelSession) Extend(addTime *big.Int) (*types.Transaction, error) {
+	return _Channel.Contract.Extend(&_Channel.TransactOpts, addTime)
+}
+
+// Extend is a paid mutator transaction binding the contract method 0x02ef6561.
+//
+// Solidity: function Extend(uint256 addTime) returns()
+func (_Channel *ChannelTransactorSession) Extend(addTime *big.Int) (*types.Transaction, error) {
+	return _Channel.Contract.Extend(&_Channel.TransactOpts, addTime)
+}
+
+// SettlePayment is a paid mutator transaction binding the contract method 0xc328cd32.
 //
-// Solidity: function CloseChannel(bytes32 hash, uint256 value, bytes sign) payable returns()
-func (_Channel *ChannelTransactor) CloseChannel(opts *bind.TransactOpts, hash [32]byte, value *big.Int, sign []byte) (*types.Transaction, error) {
-	return _Channel.contract.Transact(opts, "CloseChannel", hash, value, sign)
+// Solidity: function SettlePayment(bytes32 hash, uint256 total, bytes sign) returns()
+func (_Channel *ChannelTransactor) SettlePayment(opts *bind.TransactOpts, hash [32]byte, total *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.contract.Transact(opts, "SettlePayment", hash, total, sign)
 }
 
-// CloseChannel is a paid mutator transaction binding the contract method 0x2b7fa6be.
+// SettlePayment is a paid mutator transaction binding the contract method 0xc328cd32.
 //
-// Solidity: function CloseChannel(bytes32 hash, uint256 value, bytes sign) payable returns()
-func (_Channel *ChannelSession) CloseChannel(hash [32]byte, value *big.Int, sign []byte) (*types.Transaction, error) {
-	return _Channel.Contract.CloseChannel(&_Channel.TransactOpts, hash, value, sign)
+// Solidity: function SettlePayment(bytes32 hash, uint256 total, bytes sign) returns()
+func (_Channel *ChannelSession) SettlePayment(hash [32]byte, total *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.Contract.SettlePayment(&_Channel.TransactOpts, hash, total, sign)
 }
 
-// CloseChannel is a paid mutator transaction binding the contract method 0x2b7fa6be.
+// SettlePayment is a paid mutator transaction binding the contract method 0xc328cd32.
 //
-// Solidity: function CloseChannel(bytes32 hash, uint256 value, bytes sign) payable returns()
-func (_Channel *ChannelTransactorSession) CloseChannel(hash [32]byte, value *big.Int, sign []byte) (*types.Transaction, error) {
-	return _Channel.Contract.CloseChannel(&_Channel.TransactOpts, hash, value, sign)
+// Solidity: function SettlePayment(bytes32 hash, uint256 total, bytes sign) returns()
+func (_Channel *ChannelTransactorSession) SettlePayment(hash [32]byte, total *big.Int, sign []byte) (*types.Transaction, error) {
+	return _Channel.Contract.SettlePayment(&_Channel.TransactOpts, hash, total, sign)
 }
 
 // AlterOwner is a paid mutator transaction binding the contract method 0x0ca05f9f.
@@ -311,27 +457,6 @@ func (_Channel *ChannelTransactorSession) AlterOwner(newOwner common.Address) (*
 	return _Channel.Contract.AlterOwner(&_Channel.TransactOpts, newOwner)
 }
 
-// Extend is a paid mutator transaction binding the contract method 0x9714378c.
-//
-// Solidity: function extend(uint256 addTime) returns()
-func (_Channel *ChannelTransactor) Extend(opts *bind.TransactOpts, addTime *big.Int) (*types.Transaction, error) {
-	return _Channel.contract.Transact(opts, "extend", addTime)
-}
-
-// Extend is a paid mutator transaction binding the contract method 0x9714378c.
-//
-// Solidity: function extend(uint256 addTime) returns()
-func (_Channel *ChannelSession) Extend(addTime *big.Int) (*types.Transaction, error) {
-	return _Channel.Contract.Extend(&_Channel.TransactOpts, addTime)
-}
-
-// Extend is a paid mutator transaction binding the contract method 0x9714378c.
-//
-// Solidity: function extend(uint256 addTime) returns()
-func (_Channel *ChannelTransactorSession) Extend(addTime *big.Int) (*types.Transaction, error) {
-	return _Channel.Contract.Extend(&_Channel.TransactOpts, addTime)
-}
-
 // Receive is a paid mutator transaction binding the contract receive function.
 //
 // Solidity: receive() payable returns()
@@ -487,6 +612,159 @@ func (_Channel *ChannelFilterer) ParseAlterOwner(log types.Log) (*ChannelAlterOw
 	return event, nil
 }
 
+// ChannelChannelPayIterator is returned from FilterChannelPay and is used to iterate over the raw logs and unpacked data for ChannelPay events raised by the Channel contract.
+type ChannelChannelPayIterator struct {
+	Event *ChannelChannelPay // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *ChannelChannelPayIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(ChannelChannelPay)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(ChannelChannelPay)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *ChannelChannelPayIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *ChannelChannelPayIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// ChannelChannelPay represents a ChannelPay event raised by the Channel contract.
+type ChannelChannelPay struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterChannelPay is a free log retrieval operation binding the contract event 0x5f8385d57977d2bf0444ccd54a1135dba3f6e45556c5164e3f4228cf7b3db2a5.
+//
+// Solidity: event channelPay(address indexed from, address indexed to, uint256 value)
+func (_Channel *ChannelFilterer) FilterChannelPay(opts *bind.FilterOpts, from []common.Address, to []common.Address) (*ChannelChannelPayIterator, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _Channel.contract.FilterLogs(opts, "channelPay", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelChannelPayIterator{contract: _Channel.contract, event: "channelPay", logs: logs, sub: sub}, nil
+}
+
+// WatchChannelPay is a free log subscription operation binding the contract event 0x5f8385d57977d2bf0444ccd54a1135dba3f6e45556c5164e3f4228cf7b3db2a5.
+//
+// Solidity: event channelPay(address indexed from, address indexed to, uint256 value)
+func (_Channel *ChannelFilterer) WatchChannelPay(opts *bind.WatchOpts, sink chan<- *ChannelChannelPay, from []common.Address, to []common.Address) (event.Subscription, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _Channel.contract.WatchLogs(opts, "channelPay", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(ChannelChannelPay)
+				if err := _Channel.contract.UnpackLog(event, "channelPay", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseChannelPay is a log parse operation binding the contract event 0x5f8385d57977d2bf0444ccd54a1135dba3f6e45556c5164e3f4228cf7b3db2a5.
+//
+// Solidity: event channelPay(address indexed from, address indexed to, uint256 value)
+func (_Channel *ChannelFilterer) ParseChannelPay(log types.Log) (*ChannelChannelPay, error) {
+	event := new(ChannelChannelPay)
+	if err := _Channel.contract.UnpackLog(event, "channelPay", log); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
 // ChannelCloseChannelIterator is returned from FilterCloseChannel and is used to iterate over the raw logs and unpacked data for CloseChannel events raised by the Channel contract.
 type ChannelCloseChannelIterator struct {
 	Event *ChannelCloseChannel // Event containing the contract specifics and raw log
@@ -556,22 +834,22 @@ func (it *ChannelCloseChannelIterator) Close() error {
 
 // ChannelCloseChannel represents a CloseChannel event raised by the Channel contract.
 type ChannelCloseChannel struct {
-	From  common.Address
-	Value *big.Int
-	Raw   types.Log // Blockchain specific contextual infos
+	Sender common.Address
+	Value  *big.Int
+	Raw    types.Log // Blockchain specific contextual infos
 }
 
 // FilterCloseChannel is a free log retrieval operation binding the contract event 0x01d42a9c1bb0e1a3464994bd2306368ef80e0dcf460c6123b5f7cbbcbf169fbb.
 //
-// Solidity: event closeChannel(address indexed from, uint256 value)
-func (_Channel *ChannelFilterer) FilterCloseChannel(opts *bind.FilterOpts, from []common.Address) (*ChannelCloseChannelIterator, error) {
+// Solidity: event closeChannel(address indexed sender, uint256 value)
+func (_Channel *ChannelFilterer) FilterCloseChannel(opts *bind.FilterOpts, sender []common.Address) (*ChannelCloseChannelIterator, error) {
 
-	var fromRule []interface{}
-	for _, fromItem := range from {
-		fromRule = append(fromRule, fromItem)
+	var senderRule []interface{}
+	for _, senderItem := range sender {
+		senderRule = append(senderRule, senderItem)
 	}
 
-	logs, sub, err := _Channel.contract.FilterLogs(opts, "closeChannel", fromRule)
+	logs, sub, err := _Channel.contract.FilterLogs(opts, "closeChannel", senderRule)
 	if err != nil {
 		return nil, err
 	}
@@ -580,15 +858,15 @@ func (_Channel *ChannelFilterer) FilterCloseChannel(opts *bind.FilterOpts, from
 
 // WatchCloseChannel is a free log subscription operation binding the contract event 0x01d42a9c1bb0e1a3464994bd2306368ef80e0dcf460c6123b5f7cbbcbf169fbb.
 //
-// Solidity: event closeChannel(address indexed from, uint256 value)
-func (_Channel *ChannelFilterer) WatchCloseChannel(opts *bind.WatchOpts, sink chan<- *ChannelCloseChannel, from []common.Address) (event.Subscription, error) {
+// Solidity: event closeChannel(address indexed sender, uint256 value)
+func (_Channel *ChannelFilterer) WatchCloseChannel(opts *bind.WatchOpts, sink chan<- *ChannelCloseChannel, sender []common.Address) (event.Subscription, error) {
 
-	var fromRule []interface{}
-	for _, fromItem := range from {
-		fromRule = append(fromRule, fromItem)
+	var senderRule []interface{}
+	for _, senderItem := range sender {
+		senderRule = append(senderRule, senderItem)
 	}
 
-	logs, sub, err := _Channel.contract.WatchLogs(opts, "closeChannel", fromRule)
+	logs, sub, err := _Channel.contract.WatchLogs(opts, "closeChannel", senderRule)
 	if err != nil {
 		return nil, err
 	}
@@ -622,7 +900,7 @@ func (_Channel *ChannelFilterer) WatchCloseChannel(opts *bind.WatchOpts, sink ch
 
 // ParseCloseChannel is a log parse operation binding the contract event 0x01d42a9c1bb0e1a3464994bd2306368ef80e0dcf460c6123b5f7cbbcbf169fbb.
 //
-// Solidity: event closeChannel(address indexed from, uint256 value)
+// Solidity: event closeChannel(address indexed sender, uint256 value)
 func (_Channel *ChannelFilterer) ParseCloseChannel(log types.Log) (*ChannelCloseChannel, error) {
 	event := new(ChannelCloseChannel)
 	if err := _Channel.contract.UnpackLog(event, "closeChannel", log); err != nil {
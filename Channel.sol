@@ -12,23 +12,32 @@ contract Channel is Owned, ChannelIn {
     address payable channelSender; //payer
     address[] channelRecipients; //receiver
     mapping(address => mapping(uint => bool)) nonces; //avoid replay attack
+    mapping(address => uint256) withdrawn; //paid to each recipient by cumulative vouchers
 
     uint256 startDate; //start date
     uint256 timeOut; //number of seconds to time out
 
     adminOwned admin = adminOwned(0x8026796Fd7cE63EAe824314AA5bacF55643e893d); //adminOwned-contract address
-    uint16 constant version = 1; //contract version；
+    address immutable factory; //ChannelFactory-contract which deployed this channel, 0 if deployed directly
+    uint16 constant version = 2; //contract version；
+
+    // the code is gone after ChannelTimeout, the event tells a destroyed channel from one never deployed.
+    event closeChannel(address indexed sender, uint256 value);
 
     receive() external payable {}
 
-    constructor(address[] memory to, uint256 timeout) payable {
+    // sender is msg.sender when deployed directly, the payer when deployed by the ChannelFactory at channelFactory;
+    // a channel naming another sender records the factory which deployed it, see GetFactory.
+    constructor(address payable sender, address[] memory to, uint256 timeout, address channelFactory) payable {
+        require(sender == msg.sender || msg.sender == channelFactory, "illegal sender");
         uint16 bannedVersion = admin.getChannelBannedVersion();
         require(bannedVersion < version, "deploy channel is banned");
         require(timeout > 0);
         channelRecipients = to;
-        channelSender = payable(msg.sender);
+        channelSender = sender;
         startDate = block.timestamp;
         timeOut = timeout;
+        factory = channelFactory;
     }
 
     // called by receiver.
@@ -48,6 +57,25 @@ contract Channel is Owned, ChannelIn {
         emit channelPay(channelSender, msg.sender, value);
     }
 
+    // called by receiver with the latest cumulative voucher, which states the total owed so far;
+    // only the part not withdrawn yet is paid, so one transaction settles all vouchers before it.
+    function SettlePayment(bytes32 hash, uint256 total, bytes memory sign) external {
+        require(isRecipient(msg.sender), "illegal caller");
+        require(total > withdrawn[msg.sender], "illegal total");
+
+        bytes32 proof = keccak256(abi.encodePacked(address(this), total, msg.sender));
+        require(proof == hash, "illegal hash");
+
+        address send = hash.recover(sign);
+        require(send == channelSender, "illegal sig");
+
+        uint256 value = total - withdrawn[msg.sender];
+        withdrawn[msg.sender] = total;
+
+        payable(msg.sender).transfer(value); //pay the difference to receiver
+        emit channelPay(channelSender, msg.sender, value);
+    }
+
     function isRecipient(address recipient) internal view returns(bool) {
         for(uint256 i=0; i<channelRecipients.length; i++){
             if(channelRecipients[i] == recipient){
@@ -61,6 +89,7 @@ contract Channel is Owned, ChannelIn {
     function ChannelTimeout() external override {
         require(startDate + timeOut > startDate);
         require(startDate + timeOut <= block.timestamp, "Time is not up");
+        emit closeChannel(channelSender, address(this).balance);
         selfdestruct(channelSender);
     }
 
@@ -82,6 +111,18 @@ contract Channel is Owned, ChannelIn {
         return nonces[recipient][nonce];
     }
 
+    function GetWithdrawn(address recipient) external view returns(uint256){
+        return withdrawn[recipient];
+    }
+
+    function GetVersion() external pure returns(uint16){
+        return version;
+    }
+
+    function GetFactory() external view returns(address){
+        return factory;
+    }
+
     function Extend(uint256 addTime) external override onlyOwner {
         uint16 bannedVersion = admin.getChannelBannedVersion();
         require(bannedVersion < version, "extend is banned");
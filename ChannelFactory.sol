@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: GPL-3.0
+pragma solidity ^0.8.0;
+
+import "./Channel.sol";
+
+// deploys channels by CREATE2, so the address is known before the deployment is mined.
+contract ChannelFactory {
+    event ChannelCreated(address indexed sender, address indexed channel, uint256 sequence);
+
+    // the salt binds the channel to its payer, nobody else can take the address.
+    function channelSalt(address sender, address[] memory to, uint256 sequence) public pure returns (bytes32) {
+        return keccak256(abi.encodePacked(sender, to, sequence));
+    }
+
+    // called by payer, msg.value goes to the channel.
+    function CreateChannel(address[] memory to, uint256 timeout, uint256 sequence) external payable returns (address) {
+        Channel ch = new Channel{salt: channelSalt(msg.sender, to, sequence), value: msg.value}(payable(msg.sender), to, timeout, address(this));
+        ch.alterOwner(msg.sender); // the factory is the owner after new, hand it to payer
+        emit ChannelCreated(msg.sender, address(ch), sequence);
+        return address(ch);
+    }
+
+    function ComputeAddress(address sender, address[] memory to, uint256 timeout, uint256 sequence) external view returns (address) {
+        bytes memory code = abi.encodePacked(type(Channel).creationCode, abi.encode(sender, to, timeout, address(this)));
+        bytes32 hash = keccak256(abi.encodePacked(bytes1(0xff), address(this), channelSalt(sender, to, sequence), keccak256(code)));
+        return address(uint160(uint256(hash)));
+    }
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: GPL-3.0
+pragma solidity ^0.8.0;
+
+import "./Owned.sol";
+import "./AdminOwned.sol";
+import "./libraries/Recover.sol";
+
+interface IERC20 {
+    function transfer(address to, uint256 value) external returns (bool);
+    function transferFrom(address from, address to, uint256 value) external returns (bool);
+    function approve(address spender, uint256 value) external returns (bool);
+    function allowance(address owner, address spender) external view returns (uint256);
+    function balanceOf(address owner) external view returns (uint256);
+}
+
+// Channel paying in an ERC-20 token instead of native coin.
+contract TokenChannel is Owned {
+    using Recover for bytes32;
+
+    address payable channelSender; //payer
+    address[] channelRecipients; //receiver
+    IERC20 token; //token paid by the channel
+    mapping(address => mapping(uint => bool)) nonces; //avoid replay attack
+
+    uint256 startDate; //start date
+    uint256 timeOut; //number of seconds to time out
+
+    adminOwned admin = adminOwned(0x8026796Fd7cE63EAe824314AA5bacF55643e893d); //adminOwned-contract address
+    uint16 constant version = 2; //contract version；
+
+    event channelPay(address indexed from, address indexed to, uint256 value);
+    event channelDeposit(address indexed from, uint256 value);
+    // the code is gone after ChannelTimeout, the event tells a destroyed channel from one never deployed.
+    event closeChannel(address indexed sender, uint256 value);
+
+    // deployed by its sender only, there is no factory of token channels.
+    constructor(address payable sender, address tokenAddr, address[] memory to, uint256 timeout) {
+        require(sender == msg.sender, "illegal sender");
+        uint16 bannedVersion = admin.getChannelBannedVersion();
+        require(bannedVersion < version, "deploy channel is banned");
+        require(timeout > 0);
+        require(tokenAddr.code.length > 0, "no token");
+        channelRecipients = to;
+        channelSender = sender;
+        token = IERC20(tokenAddr);
+        startDate = block.timestamp;
+        timeOut = timeout;
+    }
+
+    // calls token like SafeERC20 does: tokens such as USDT return nothing from transfer and transferFrom,
+    // only a false returned or a revert is a failure.
+    function callToken(bytes memory data) internal {
+        (bool ok, bytes memory ret) = address(token).call(data);
+        require(ok && (ret.length == 0 || abi.decode(ret, (bool))), "transfer fails");
+    }
+
+    // called by anyone after approving the channel, usually the sender.
+    function Deposit(uint256 value) external {
+        callToken(abi.encodeWithSelector(token.transferFrom.selector, msg.sender, address(this), value));
+        emit channelDeposit(msg.sender, value);
+    }
+
+    // called by receiver.
+    function DemandPayment(bytes32 hash, uint256 value, uint nonce, bytes memory sign) external {
+        require(isRecipient(msg.sender), "illegal caller");
+        require(!nonces[msg.sender][nonce], "illegal nonce");
+
+        bytes32 proof = keccak256(abi.encodePacked(address(this), address(token), value, nonce, msg.sender));
+        require(proof == hash, "illegal hash");
+
+        address send = hash.recover(sign);
+        require(send == channelSender, "illegal sig");
+
+        nonces[msg.sender][nonce] = true;
+
+        callToken(abi.encodeWithSelector(token.transfer.selector, msg.sender, value)); //pay value to receiver
+        emit channelPay(channelSender, msg.sender, value);
+    }
+
+    function isRecipient(address recipient) internal view returns(bool) {
+        for(uint256 i=0; i<channelRecipients.length; i++){
+            if(channelRecipients[i] == recipient){
+                return true;
+            }
+        }
+        return false;
+    }
+
+    // user call, the tokens left go back to sender.
+    function ChannelTimeout() external {
+        require(startDate + timeOut > startDate);
+        require(startDate + timeOut <= block.timestamp, "Time is not up");
+        uint256 left = token.balanceOf(address(this));
+        if (left > 0) {
+            callToken(abi.encodeWithSelector(token.transfer.selector, channelSender, left));
+        }
+        emit closeChannel(channelSender, left);
+        selfdestruct(channelSender);
+    }
+
+    function GetInfo()
+        external
+        view
+        returns (
+            uint256,
+            uint256,
+            address,
+            address[] memory
+        )
+    {
+        return (startDate, timeOut, channelSender, channelRecipients);
+    }
+
+    function GetToken() external view returns(address){
+        return address(token);
+    }
+
+    function GetNonceValue(address recipient, uint nonce) external view returns(bool){
+        return nonces[recipient][nonce];
+    }
+
+    function GetVersion() external pure returns(uint16){
+        return version;
+    }
+
+    function Extend(uint256 addTime) external onlyOwner {
+        uint16 bannedVersion = admin.getChannelBannedVersion();
+        require(bannedVersion < version, "extend is banned");
+        require(addTime > 0); // 只能延长
+        uint256 timeout = timeOut + addTime;
+        require(timeout > timeOut);
+        timeOut = timeout;
+    }
+}
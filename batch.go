@@ -0,0 +1,345 @@
+package contracts
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+//Payment one voucher to redeem by DemandPayments
+type Payment struct {
+	Channel common.Address
+	Value   *big.Int
+	Nonce   *big.Int
+	Sig     []byte
+}
+
+//PaymentResult the outcome of one Payment
+type PaymentResult struct {
+	Payment
+	TxHash  common.Hash    //empty if no transaction is sent
+	Receipt *types.Receipt //nil if the transaction is not mined
+	Err     error          //nil if the value is paid
+}
+
+//DemandPayments called by provider to redeem many vouchers at once, see DemandPaymentsWithContext
+func (ch *ChannelNodeInfo) DemandPayments(payments []Payment) []*PaymentResult {
+	return ch.DemandPaymentsWithContext(context.Background(), payments)
+}
+
+//DemandPaymentsWithContext sends one DemandPayment transaction for each payment with consecutive nonces
+//from the NonceManager of the policy without waiting in between, then waits for all receipts; results are in the order of payments;
+//the transactions are priced as SendTx does, and one not mined in time is rebuilt with the same nonce and bumped fees
+//unless the watchdog of the policy replaces it
+func (ch *ChannelNodeInfo) DemandPaymentsWithContext(ctx context.Context, payments []Payment) []*PaymentResult {
+	results := make([]*PaymentResult, len(payments))
+	for i, p := range payments {
+		results[i] = &PaymentResult{Payment: p}
+	}
+
+	//fail sets err to every payment not mined yet, including those sent
+	fail := func(err error) []*PaymentResult {
+		for _, r := range results {
+			if r.Err == nil && r.Receipt == nil {
+				r.Err = err
+			}
+		}
+		return results
+	}
+
+	if ch.signer == nil {
+		return fail(ErrNoSigner)
+	}
+	policy := ch.policy
+	if policy == nil {
+		policy = DefaultTxPolicy()
+	}
+
+	nonces := policy.Nonces
+	if nonces == nil {
+		nonces = NewNonceManager()
+	}
+	backend := ch.getBackend()
+
+	txSigner, err := chainSigner(ctx, backend, policy)
+	if err != nil {
+		return fail(err)
+	}
+	feeCaller, dynChainID, err := dynamicFeeChain(ctx, backend, policy)
+	if err != nil {
+		return fail(err)
+	}
+	s := &paymentSender{
+		ch:       ch,
+		backend:  backend,
+		policy:   policy,
+		nonces:   nonces,
+		txSigner: txSigner,
+		caller:   feeCaller,
+		chainID:  dynChainID,
+	}
+
+	log.Println("begin call demandPayments of", len(payments), "vouchers...")
+
+	//依次发送，交易nonce连续，不等待上链
+	txs := make([]*paymentTx, len(payments))
+	for i, p := range payments {
+		if ctx.Err() != nil {
+			return fail(ctx.Err())
+		}
+
+		ptx, err := s.send(ctx, p, nil)
+		if err != nil {
+			log.Println("demandPayment of channel", p.Channel.String(), "fails:", err)
+			results[i].Err = err
+			continue
+		}
+		txs[i] = ptx
+		results[i].TxHash = ptx.hash
+		//the watchdog replaces legacy transactions only
+		if policy.Watchdog != nil && ptx.dyn == nil {
+			errTrack := policy.Watchdog.Track(ctx, OpPay, ch.signer, ptx.tx)
+			if errTrack != nil {
+				log.Println("track demandPayment transaction fails:", errTrack)
+			}
+		}
+	}
+
+	//再等待所有交易上链，超时未上链的交易以原nonce重建
+	for i, ptx := range txs {
+		if ptx == nil {
+			continue
+		}
+
+		ptx, receipt, err := s.wait(ctx, payments[i], ptx)
+		results[i].Receipt = receipt
+		results[i].TxHash = ptx.hash
+		if receipt != nil {
+			results[i].TxHash = receipt.TxHash
+		}
+		if err == ErrTxFail {
+			if errRevert := simulateTx(ctx, backend, ch.signer.Address(), ptx.tx); errRevert != nil {
+				err = errRevert
+			}
+		}
+		if depth := policy.Confirmations.depth(OpPay); err == nil && depth > 0 {
+			results[i].Receipt, err = confirmTx(ctx, backend, receipt, depth, OpPay, policy.OnReorg)
+		}
+		if err != nil {
+			log.Println("demandPayment of channel", payments[i].Channel.String(), "fails:", err)
+			results[i].Err = err
+		}
+	}
+
+	return results
+}
+
+//paymentSender sends the DemandPayment transactions of one DemandPayments call
+type paymentSender struct {
+	ch       *ChannelNodeInfo
+	backend  ChannelBackend
+	policy   *TxPolicy
+	nonces   *NonceManager
+	txSigner types.Signer //signs legacy transactions
+	caller   RPCCaller    //sends dynamic-fee transactions, nil if they are legacy ones
+	chainID  *big.Int     //chain of dynamic-fee transactions
+}
+
+//paymentTx the last version sent of the transaction of a Payment
+type paymentTx struct {
+	tx   *types.Transaction //the legacy form bind makes if it is sent as dyn
+	dyn  *DynamicFeeTx      //nil if tx is sent as it is
+	hash common.Hash        //hash of the version sent
+	sent []common.Hash      //hashes of all versions sent with the nonce of tx
+}
+
+//send sends the DemandPayment transaction of p with the next nonce of s.nonces and the fees of a new transaction;
+//with last it replaces last by the same nonce and bumped fees
+func (s *paymentSender) send(ctx context.Context, p Payment, last *paymentTx) (*paymentTx, error) {
+	ch, policy := s.ch, s.policy
+	channelInstance, err := channel.NewChannel(p.Channel, s.backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if last == nil {
+		used, err := channelInstance.GetNonceValue(&bind.CallOpts{
+			From:    ch.addr,
+			Context: ctx,
+		}, ch.addr, p.Nonce)
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, ErrNonceUsed
+		}
+	}
+
+	hash := paymentHash(p.Channel, p.Value, p.Nonce, ch.addr)
+	build := func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.DemandPayment(auth, hash, p.Value, p.Nonce, p.Sig)
+	}
+
+	retryCount := 0
+	for {
+		auth, err := makeSignerAuth(ch.signer, s.txSigner, nil, nil, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		auth.Context = ctx
+
+		var base, tip *big.Int
+		if s.caller != nil {
+			base, err = baseFee(ctx, s.caller)
+			if err != nil {
+				return nil, err
+			}
+			if base == nil {
+				base = new(big.Int)
+			}
+		}
+
+		//动态费用交易的GasPrice即fee cap
+		var release func(sent bool)
+		if last != nil {
+			auth.Nonce = new(big.Int).SetUint64(last.tx.Nonce())
+			auth.GasLimit = last.tx.Gas()
+			if last.dyn != nil {
+				tip, auth.GasPrice, err = bumpGasFees(last.dyn.GasTipCap, last.dyn.GasFeeCap, base, policy)
+			} else {
+				auth.GasPrice, err = bumpGasPrice(last.tx.GasPrice(), policy)
+			}
+			if err != nil {
+				return nil, err
+			}
+			log.Println("rebuild demandPayment transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice, " tip is ", tip)
+		} else {
+			nonce, rel, err := s.nonces.Acquire(ctx, s.backend, ch.signer.Address())
+			if err != nil {
+				return nil, err
+			}
+			auth.Nonce = new(big.Int).SetUint64(nonce)
+			release = rel
+
+			if s.caller != nil {
+				tip, auth.GasPrice, err = gasFees(ctx, s.caller, base, policy)
+			} else {
+				auth.GasPrice, err = gasPrice(ctx, s.backend, policy)
+			}
+			if err != nil {
+				release(false)
+				return nil, err
+			}
+			auth.GasLimit, err = gasLimit(ctx, s.backend, ch.signer.Address(), nil, auth.GasPrice, policy, OpPay, build)
+			if err != nil {
+				release(false)
+				return nil, err
+			}
+		}
+
+		var sentLegacy *types.Transaction
+		var sentDyn *DynamicFeeTx
+		if s.caller != nil {
+			dynamicFeeAuth(auth, s.caller, ch.signer, s.chainID, tip, auth.GasPrice, func(legacy *types.Transaction, tx *DynamicFeeTx) {
+				sentLegacy, sentDyn = legacy, tx
+			})
+		}
+
+		tx, err := build(auth)
+		if err == errDynamicSent {
+			tx, err = sentLegacy, nil
+		}
+		if release != nil {
+			release(err == nil)
+		}
+		if err == nil {
+			ptx := &paymentTx{tx: tx, dyn: sentDyn, hash: tx.Hash()}
+			if sentDyn != nil {
+				ptx.hash, err = sentDyn.Hash()
+				if err != nil {
+					return nil, err
+				}
+			}
+			if last != nil {
+				ptx.sent = append(ptx.sent, last.sent...)
+			}
+			ptx.sent = append(ptx.sent, ptx.hash)
+			return ptx, nil
+		}
+
+		if errRevert := decodeRevert(err); errRevert != nil {
+			return nil, errRevert
+		}
+		retryCount++
+		if retryCount > policy.SendRetry {
+			return nil, err
+		}
+		if err.Error() == core.ErrNonceTooLow.Error() {
+			//重建的交易nonce已上链，由wait查看是哪个版本
+			if last != nil {
+				return nil, err
+			}
+			//nonce被其他交易占用，release(false)之后重新从链上获取
+			continue
+		}
+		if !sleepWithContext(ctx, policy.RetrySleep) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+//wait waits for the receipt of ptx, the transaction of p; through the watchdog if it tracks ptx,
+//otherwise ptx is rebuilt by send whenever it is not mined within policy.MineTimeout, at most policy.CheckRetry times;
+//it returns the last version sent, a reverted transaction gives ErrTxFail
+func (s *paymentSender) wait(ctx context.Context, p Payment, ptx *paymentTx) (*paymentTx, *types.Receipt, error) {
+	if s.policy.Watchdog != nil && ptx.dyn == nil {
+		receipt, err := s.policy.Watchdog.Wait(ctx, s.ch.signer.Address(), ptx.tx)
+		return ptx, receipt, err
+	}
+
+	//mined returns a receipt of some version of ptx
+	mined := func(receipt *types.Receipt) (*paymentTx, *types.Receipt, error) {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return ptx, receipt, ErrTxFail
+		}
+		return ptx, receipt, nil
+	}
+
+	checkRetryCount := 0
+	for {
+		receipt, err := waitTx(ctx, s.backend, ptx.hash, s.policy.MineTimeout)
+		if err != ErrTxNotMined {
+			return ptx, receipt, err
+		}
+
+		//an earlier version may be mined instead of ptx
+		receipt, errReceipt := minedReceipt(ctx, s.backend, ptx.sent)
+		if errReceipt != nil {
+			log.Println("get receipts of demandPayment transaction fails:", errReceipt)
+		}
+		if receipt != nil {
+			return mined(receipt)
+		}
+
+		checkRetryCount++
+		if checkRetryCount > s.policy.CheckRetry {
+			return ptx, nil, err
+		}
+		next, errSend := s.send(ctx, p, ptx)
+		if errSend != nil {
+			//the nonce may be used by a version mined in the meantime
+			receipt, errReceipt := minedReceipt(ctx, s.backend, ptx.sent)
+			if errReceipt == nil && receipt != nil {
+				return mined(receipt)
+			}
+			return ptx, nil, errSend
+		}
+		ptx = next
+	}
+}
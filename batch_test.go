@@ -0,0 +1,156 @@
+package contracts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+// batchChain deploys a channel of payer to a funded provider on backend, payer is its funded key
+func batchChain(t *testing.T, backend *dropBackend, payer *ecdsa.PrivateKey) (*ecdsa.PrivateKey, common.Address) {
+	provider, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	auth := bind.NewKeyedTransactor(payer)
+	auth.Value = big.NewInt(1000000)
+	channelAddr, tx, _, err := channel.DeployChannel(auth, backend, auth.From, []common.Address{crypto.PubkeyToAddress(provider.PublicKey)}, big.NewInt(365*24*3600), common.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bind.WaitDeployed(ctx, backend, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = types.SignTx(types.NewTransaction(tx.Nonce()+1, crypto.PubkeyToAddress(provider.PublicKey), big.NewInt(100000000000000000), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = backend.SendTransaction(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, channelAddr
+}
+
+// batchPayment a voucher of value and nonce signed by payer
+func batchPayment(t *testing.T, payer, provider *ecdsa.PrivateKey, channelAddr common.Address, value, nonce int64) Payment {
+	hash := paymentHash(channelAddr, big.NewInt(value), big.NewInt(nonce), crypto.PubkeyToAddress(provider.PublicKey))
+	sig, err := crypto.Sign(hash[:], payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Payment{Channel: channelAddr, Value: big.NewInt(value), Nonce: big.NewInt(nonce), Sig: sig}
+}
+
+func batchPolicy() *TxPolicy {
+	policy := testPolicy()
+	policy.MineTimeout = 200 * time.Millisecond
+	policy.GasMultiplier = defaultGasMultiplier
+	return policy
+}
+
+func TestDemandPaymentsRebuild(t *testing.T) {
+	payer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newDropBackend(t, payer, 0)
+	provider, channelAddr := batchChain(t, backend, payer)
+
+	// both transactions of the batch fall out of the pool
+	first := len(backend.sent)
+	backend.drop = first + 2
+	ch := &ChannelNodeInfo{
+		addr:    crypto.PubkeyToAddress(provider.PublicKey),
+		signer:  NewECDSASigner(provider),
+		policy:  batchPolicy(),
+		backend: backend,
+	}
+	payments := []Payment{
+		batchPayment(t, payer, provider, channelAddr, 100, 1),
+		batchPayment(t, payer, provider, channelAddr, 200, 2),
+	}
+	results := ch.DemandPayments(payments)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("payment %d: %v", i, r.Err)
+		}
+	}
+
+	// each is rebuilt with its own nonce and a bumped gas price
+	sent := backend.sent[first:]
+	if len(sent) != 4 {
+		t.Fatalf("sent %d transactions, want 4", len(sent))
+	}
+	for i, r := range results {
+		old, replaced := sent[i], sent[i+2]
+		if replaced.Nonce() != old.Nonce() || replaced.GasPrice().Cmp(old.GasPrice()) <= 0 {
+			t.Fatalf("payment %d is not replaced", i)
+		}
+		if r.TxHash != replaced.Hash() || r.Receipt == nil {
+			t.Fatalf("result %d is not of the replacement", i)
+		}
+	}
+	if sent[0].Nonce()+1 != sent[1].Nonce() {
+		t.Fatal("nonces of the batch are not consecutive")
+	}
+}
+
+func TestDemandPaymentsDynamicFee(t *testing.T) {
+	payer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	drop := newDropBackend(t, payer, 0)
+	provider, channelAddr := batchChain(t, drop, payer)
+	backend := &feeBackend{dropBackend: drop, baseFee: big.NewInt(10), tip: big.NewInt(2)}
+
+	ch := &ChannelNodeInfo{
+		addr:    crypto.PubkeyToAddress(provider.PublicKey),
+		signer:  NewECDSASigner(provider),
+		policy:  batchPolicy(),
+		backend: backend,
+	}
+	ch.policy.GasPrice = nil
+	results := ch.DemandPayments([]Payment{batchPayment(t, payer, provider, channelAddr, 100, 1)})
+	if results[0].Err != ErrTxNotMined {
+		t.Fatalf("got %v, want %v", results[0].Err, ErrTxNotMined)
+	}
+
+	// sent as dynamic-fee transactions and replaced with bumped fees, as SendTx does
+	fees := [][2]int64{{2, 22}, {3, 25}, {4, 28}}
+	if len(backend.raw) != len(fees) {
+		t.Fatalf("sent %d dynamic-fee transactions, want %d", len(backend.raw), len(fees))
+	}
+	for i, tx := range backend.raw {
+		if tx.Nonce != 0 || *tx.To != channelAddr {
+			t.Fatalf("attempt %d is not a replacement", i)
+		}
+		if tx.GasTipCap.Int64() != fees[i][0] || tx.GasFeeCap.Int64() != fees[i][1] {
+			t.Fatalf("attempt %d pays tip %s, fee cap %s, want %v", i, tx.GasTipCap, tx.GasFeeCap, fees[i])
+		}
+	}
+	last, err := backend.raw[2].Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].TxHash != last {
+		t.Fatal("result is not of the last transaction sent")
+	}
+}
@@ -1,167 +1,326 @@
 package contracts
 
 import (
+	"context"
+	"errors"
 	"log"
 	"math/big"
-	"time"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/memoio/go-mefs/contracts/channel"
 )
 
+//ErrNoRecipient channel-contract must have at least one recipient
+var ErrNoRecipient = errors.New("no recipient for channel")
+
+//ErrNonceUsed the nonce of the voucher has already been paid by the channel-contract
+var ErrNonceUsed = errors.New("channel nonce has been used")
+
+//ErrTopUpAmount the money added to a channel-contract must be positive
+var ErrTopUpAmount = errors.New("top-up amount must be positive")
+
 //ChannelInfo  The basic information of node used for channel contract
 type ChannelNodeInfo struct {
-	addr  common.Address //local address
-	hexSk string         //local privateKey
+	addr    common.Address //local address
+	signer  Signer         //signs the channel transactions
+	policy  *TxPolicy      //retry and gas policy of transactions
+	backend ChannelBackend //chain to talk to, nil means the node at EndPoint
+	mapper  ChannelMapper  //records the channels on the chain of backend, nil if there is none
+	factory common.Address //ChannelFactory-contract of DeployChannelByFactory, zero if there is none
+
+	clientOnce sync.Once      //dials the node at EndPoint once
+	client     ChannelBackend //the node at EndPoint if backend is nil
+}
+
+//CHOption configures the instance returned by NewCH
+type CHOption func(ch *ChannelNodeInfo)
+
+//WithBackend let the instance use backend instead of the node at EndPoint
+func WithBackend(backend ChannelBackend) CHOption {
+	return func(ch *ChannelNodeInfo) {
+		ch.backend = backend
+	}
+}
+
+//WithMapper let the instance record and look up channels in mapper,
+//which must be on the chain of its backend
+func WithMapper(mapper ChannelMapper) CHOption {
+	return func(ch *ChannelNodeInfo) {
+		ch.mapper = mapper
+	}
+}
+
+//WithChannelFactory let the instance deploy channels by the ChannelFactory-contract at factory,
+//which must be on the chain of its backend
+func WithChannelFactory(factory common.Address) CHOption {
+	return func(ch *ChannelNodeInfo) {
+		ch.factory = factory
+	}
+}
+
+//WithSigner let the instance sign channel transactions with signer instead of its hex key
+func WithSigner(signer Signer) CHOption {
+	return func(ch *ChannelNodeInfo) {
+		ch.signer = signer
+	}
 }
 
-//NewCH new a instance of contractChannel
-func NewCH(addr common.Address, hexSk string) ContractChannel {
+//NewCH new a instance of contractChannel, hexSk may be empty if a signer is given by WithSigner
+func NewCH(addr common.Address, hexSk string, opts ...CHOption) (ContractChannel, error) {
 	ChInfo := &ChannelNodeInfo{
-		addr:  addr,
-		hexSk: hexSk,
+		addr:   addr,
+		policy: DefaultTxPolicy(),
+	}
+
+	if hexSk != "" {
+		signer, err := NewKeySigner(hexSk)
+		if err != nil {
+			return nil, err
+		}
+		ChInfo.signer = signer
+	}
+
+	for _, opt := range opts {
+		opt(ChInfo)
 	}
 
-	return ChInfo
+	//ContractManage只连接EndPoint，其他backend的mapper需由WithMapper给出
+	if ChInfo.mapper == nil && ChInfo.backend == nil {
+		ChInfo.mapper = newCManageMapper(addr, hexSk)
+	}
+
+	return ChInfo, nil
+}
+
+//NewCHWithSigner new a instance of contractChannel whose transactions are signed by signer;
+//without a hex key it has no mapper at EndPoint, give one by WithMapper or use channels deployed elsewhere
+func NewCHWithSigner(signer Signer, opts ...CHOption) (ContractChannel, error) {
+	return NewCH(signer.Address(), "", append([]CHOption{WithSigner(signer)}, opts...)...)
+}
+
+//getBackend returns the backend of ch, the node at EndPoint by default, which is dialed once
+func (ch *ChannelNodeInfo) getBackend() ChannelBackend {
+	if ch.backend != nil {
+		return ch.backend
+	}
+	ch.clientOnce.Do(func() {
+		ch.client = getClient(EndPoint)
+	})
+	return ch.client
+}
+
+//getMapper returns the mapper of ch, ErrNoMapper if it has none
+func (ch *ChannelNodeInfo) getMapper() (ChannelMapper, error) {
+	if ch.mapper == nil {
+		return nil, ErrNoMapper
+	}
+	return ch.mapper, nil
+}
+
+//SetTxPolicy set the retry and gas policy used by all transactions of ch
+func (ch *ChannelNodeInfo) SetTxPolicy(policy *TxPolicy) {
+	ch.policy = policy
 }
 
 //DeployChannelContract deploy channel-contract, timeOut's unit is second
 func (ch *ChannelNodeInfo) DeployChannelContract(queryAddress, providerAddress common.Address, timeOut *big.Int, moneyToChannel *big.Int, redo bool) (common.Address, error) {
+	return ch.DeployChannelContractWithContext(context.Background(), queryAddress, providerAddress, timeOut, moneyToChannel, redo)
+}
+
+//DeployChannelContractWithContext is DeployChannelContract which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DeployChannelContractWithContext(ctx context.Context, queryAddress, providerAddress common.Address, timeOut *big.Int, moneyToChannel *big.Int, redo bool) (common.Address, error) {
 	var channelAddr common.Address
+	if ctx.Err() != nil {
+		return channelAddr, ctx.Err()
+	}
 
 	key := queryAddress.String() + channelKey + providerAddress.String()
 
-	ma := NewCManage(ch.addr, ch.hexSk)
-	_, mapperInstance, err := ma.GetMapperFromAdmin(ch.addr, key, true)
+	mapper, err := ch.getMapper()
 	if err != nil {
 		return channelAddr, err
 	}
 
 	if !redo {
-		channelAddr, err = ma.GetLatestFromMapper(mapperInstance)
+		channelAddr, err = mapper.GetLatestChannel(ctx, ch.addr, key)
 		if err == nil {
 			return channelAddr, nil
 		}
 	}
 
-	log.Println("begin deploy channel contract...")
-	client := getClient(EndPoint)
-
 	//本user与指定的provider部署channel合约
-	tx := &types.Transaction{}
-	retryCount := 0
-	checkRetryCount := 0
-	var cAddr common.Address
-	for {
-		auth, errMA := makeAuth(ch.hexSk, moneyToChannel, nil, big.NewInt(defaultGasPrice), defaultGasLimit)
-		if errMA != nil {
-			return channelAddr, errMA
-		}
+	channelAddr, err = ch.deployChannel(ctx, []common.Address{providerAddress}, timeOut, moneyToChannel)
+	if err != nil {
+		return channelAddr, err
+	}
 
-		if err == ErrTxFail && tx != nil {
-			auth.Nonce = big.NewInt(int64(tx.Nonce()))
-			auth.GasPrice = new(big.Int).Add(tx.GasPrice(), big.NewInt(defaultGasPrice))
-			log.Println("rebuild transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice)
-		}
+	//将channel合约地址channelAddr放进上述的mapper中
+	err = mapper.AddChannel(ctx, ch.addr, key, channelAddr)
+	if err != nil {
+		return channelAddr, err
+	}
 
-		cAddr, tx, _, err = channel.DeployChannel(auth, client, providerAddress, timeOut)
-		if cAddr.String() != InvalidAddr {
-			channelAddr = cAddr
-		}
-		if err != nil {
-			retryCount++
-			log.Println("deploy Channel Err:", err)
-			if err.Error() == core.ErrNonceTooLow.Error() && auth.GasPrice.Cmp(big.NewInt(defaultGasPrice)) > 0 {
-				log.Println("previously pending transaction has successfully executed")
-				break
-			}
-			if retryCount > sendTransactionRetryCount {
-				return channelAddr, err
-			}
-			time.Sleep(retryTxSleepTime)
+	return channelAddr, nil
+}
+
+//DeployMultiChannelContract deploy one channel-contract which pays all of providerAddresses,
+//the channel is recorded in the mapper of every provider; returns the recipients it was created with
+func (ch *ChannelNodeInfo) DeployMultiChannelContract(queryAddress common.Address, providerAddresses []common.Address, timeOut *big.Int, moneyToChannel *big.Int) (common.Address, []common.Address, error) {
+	return ch.DeployMultiChannelContractWithContext(context.Background(), queryAddress, providerAddresses, timeOut, moneyToChannel)
+}
+
+//DeployMultiChannelContractWithContext is DeployMultiChannelContract which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DeployMultiChannelContractWithContext(ctx context.Context, queryAddress common.Address, providerAddresses []common.Address, timeOut *big.Int, moneyToChannel *big.Int) (common.Address, []common.Address, error) {
+	var channelAddr common.Address
+
+	recipients := uniqueRecipients(providerAddresses)
+	if len(recipients) == 0 {
+		return channelAddr, nil, ErrNoRecipient
+	}
+	//没有mapper则不部署，避免合约无处记录
+	if _, err := ch.getMapper(); err != nil {
+		return channelAddr, nil, err
+	}
+
+	channelAddr, err := ch.deployChannel(ctx, recipients, timeOut, moneyToChannel)
+	if err != nil {
+		return channelAddr, recipients, err
+	}
+
+	err = ch.addToMappers(ctx, queryAddress, recipients, channelAddr)
+	return channelAddr, recipients, err
+}
+
+//uniqueRecipients returns providerAddresses without the repeated ones, in their order
+func uniqueRecipients(providerAddresses []common.Address) []common.Address {
+	recipients := make([]common.Address, 0, len(providerAddresses))
+	seen := make(map[common.Address]struct{}, len(providerAddresses))
+	for _, providerAddress := range providerAddresses {
+		if _, ok := seen[providerAddress]; ok {
 			continue
 		}
+		seen[providerAddress] = struct{}{}
+		recipients = append(recipients, providerAddress)
+	}
+	return recipients
+}
+
+//addToMappers records channelAddr in the mapper of every recipient
+func (ch *ChannelNodeInfo) addToMappers(ctx context.Context, queryAddress common.Address, recipients []common.Address, channelAddr common.Address) error {
+	mapper, err := ch.getMapper()
+	if err != nil {
+		return err
+	}
 
-		err = checkTx(tx)
+	for _, providerAddress := range recipients {
+		key := queryAddress.String() + channelKey + providerAddress.String()
+		err = mapper.AddChannel(ctx, ch.addr, key, channelAddr)
 		if err != nil {
-			checkRetryCount++
-			log.Println("deploy channel transaction fails", err)
-			if checkRetryCount > checkTxRetryCount {
-				return channelAddr, err
-			}
-			continue
+			return err
 		}
-		break
 	}
+	return nil
+}
 
-	log.Println("channel contract", channelAddr.String(), "with", providerAddress.String(), "have been successfuly deployed!")
+//deployChannel deploy a channel-contract paying recipients
+func (ch *ChannelNodeInfo) deployChannel(ctx context.Context, recipients []common.Address, timeOut *big.Int, moneyToChannel *big.Int) (common.Address, error) {
+	var channelAddr common.Address
 
-	//将channel合约地址channelAddr放进上述的mapper中
-	err = ma.AddToMapper(channelAddr, mapperInstance)
+	client := ch.getBackend()
+	res, err := SendTxWithContext(ctx, ch.getBackend(), ch.signer, moneyToChannel, ch.policy, OpDeploy, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		cAddr, tx, _, err := channel.DeployChannel(auth, client, auth.From, recipients, timeOut, common.Address{})
+		if cAddr.String() != InvalidAddr {
+			channelAddr = cAddr
+		}
+		return tx, err
+	})
 	if err != nil {
 		return channelAddr, err
 	}
+	//a dynamic-fee deploy does not give bind the address
+	if res.Receipt != nil && res.Receipt.ContractAddress.String() != InvalidAddr {
+		channelAddr = res.Receipt.ContractAddress
+	}
 
+	log.Println("channel contract", channelAddr.String(), "with", recipients, "have been successfuly deployed!")
 	return channelAddr, nil
 }
 
-func (ch *ChannelNodeInfo) GetChannelInfo(chanAddress common.Address) (int64, int64, common.Address, common.Address, error) {
-	var sender, receiver common.Address
+//GetChannelInfo get startDate, timeOut, sender and recipients of the channel contract
+func (ch *ChannelNodeInfo) GetChannelInfo(chanAddress common.Address) (int64, int64, common.Address, []common.Address, error) {
+	return ch.GetChannelInfoWithContext(context.Background(), chanAddress)
+}
+
+//GetChannelInfoWithContext is GetChannelInfo which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetChannelInfoWithContext(ctx context.Context, chanAddress common.Address) (int64, int64, common.Address, []common.Address, error) {
+	var sender common.Address
+	var receivers []common.Address
 	var startDate, timeOut *big.Int
-	channelInstance, err := channel.NewChannel(chanAddress, getClient(EndPoint))
+	channelInstance, err := channel.NewChannel(chanAddress, ch.getBackend())
 	if err != nil {
-		return 0, 0, sender, receiver, err
+		return 0, 0, sender, receivers, err
 	}
 	retryCount := 0
 	for {
 		retryCount++
-		startDate, timeOut, sender, receiver, err = channelInstance.GetInfo(&bind.CallOpts{
-			From: ch.addr,
+		startDate, timeOut, sender, receivers, err = channelInstance.GetInfo(&bind.CallOpts{
+			From:    ch.addr,
+			Context: ctx,
 		})
 		if err != nil {
 			if retryCount > sendTransactionRetryCount {
-				return 0, 0, sender, receiver, err
+				return 0, 0, sender, receivers, err
+			}
+			if !sleepWithContext(ctx, retryGetInfoSleepTime) {
+				return 0, 0, sender, receivers, ctx.Err()
 			}
-			time.Sleep(retryGetInfoSleepTime)
 			continue
 		}
 
-		return startDate.Int64(), timeOut.Int64(), sender, receiver, nil
+		return startDate.Int64(), timeOut.Int64(), sender, receivers, nil
 	}
 }
 
 //GetChannelAddrs get the channel contract's address
 func (ch *ChannelNodeInfo) GetChannelAddrs(userAddress, providerAddress, queryAddress common.Address) ([]common.Address, error) {
-	key := queryAddress.String() + channelKey + providerAddress.String()
-	ma := NewCManage(ch.addr, ch.hexSk)
-	_, mapperInstance, err := ma.GetMapperFromAdmin(userAddress, key, false)
+	return ch.GetChannelAddrsWithContext(context.Background(), userAddress, providerAddress, queryAddress)
+}
+
+//GetChannelAddrsWithContext is GetChannelAddrs which returns ctx.Err() when ctx is done
+func (ch *ChannelNodeInfo) GetChannelAddrsWithContext(ctx context.Context, userAddress, providerAddress, queryAddress common.Address) ([]common.Address, error) {
+	mapper, err := ch.getMapper()
 	if err != nil {
 		return nil, err
 	}
 
-	return ma.GetAddressFromMapper(mapperInstance)
+	key := queryAddress.String() + channelKey + providerAddress.String()
+	return mapper.GetChannels(ctx, userAddress, key)
 }
 
 //GetLatestChannel get the channel contract's address
 func (ch *ChannelNodeInfo) GetLatestChannel(userAddress, providerAddress, queryAddress common.Address) (common.Address, *channel.Channel, error) {
+	return ch.GetLatestChannelWithContext(context.Background(), userAddress, providerAddress, queryAddress)
+}
+
+//GetLatestChannelWithContext is GetLatestChannel which returns ctx.Err() when ctx is done
+func (ch *ChannelNodeInfo) GetLatestChannelWithContext(ctx context.Context, userAddress, providerAddress, queryAddress common.Address) (common.Address, *channel.Channel, error) {
 	var channelAddr common.Address
-	key := queryAddress.String() + channelKey + providerAddress.String()
-	ma := NewCManage(ch.addr, ch.hexSk)
-	_, mapperInstance, err := ma.GetMapperFromAdmin(userAddress, key, false)
+	mapper, err := ch.getMapper()
 	if err != nil {
 		return channelAddr, nil, err
 	}
 
-	channelAddr, err = ma.GetLatestFromMapper(mapperInstance)
+	key := queryAddress.String() + channelKey + providerAddress.String()
+	channelAddr, err = mapper.GetLatestChannel(ctx, userAddress, key)
 	if err != nil {
 		return channelAddr, nil, err
 	}
 
-	channelInstance, err := channel.NewChannel(channelAddr, getClient(EndPoint))
+	channelInstance, err := channel.NewChannel(channelAddr, ch.getBackend())
 	if err != nil {
 		log.Println("getChannelsErr:", err)
 		return channelAddr, nil, err
@@ -171,168 +330,122 @@ func (ch *ChannelNodeInfo) GetLatestChannel(userAddress, providerAddress, queryA
 
 //ChannelTimeout called by user to discontinue the channel-contract
 func (ch *ChannelNodeInfo) ChannelTimeout(channelAddress common.Address) (err error) {
-	channelInstance, err := channel.NewChannel(channelAddress, getClient(EndPoint))
+	return ch.ChannelTimeoutWithContext(context.Background(), channelAddress)
+}
+
+//ChannelTimeoutWithContext is ChannelTimeout which is aborted when ctx is done
+func (ch *ChannelNodeInfo) ChannelTimeoutWithContext(ctx context.Context, channelAddress common.Address) (err error) {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
 	if err != nil {
 		return err
 	}
 
-	log.Println("begin call channelTimeout...")
-	tx := &types.Transaction{}
-	retryCount := 0
-	checkRetryCount := 0
-	for {
-		auth, errMA := makeAuth(ch.hexSk, nil, nil, big.NewInt(defaultGasPrice), defaultGasLimit)
-		if errMA != nil {
-			return errMA
-		}
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpTimeout, channelInstance.ChannelTimeout)
+	return err
+}
 
-		if err == ErrTxFail && tx != nil {
-			auth.Nonce = big.NewInt(int64(tx.Nonce()))
-			auth.GasPrice = new(big.Int).Add(tx.GasPrice(), big.NewInt(defaultGasPrice))
-			log.Println("rebuild transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice)
-		}
+//DemandPayment called by provider to withdraw value from the channel-contract with the user's signature
+func (ch *ChannelNodeInfo) DemandPayment(channelAddress common.Address, value, nonce *big.Int, sig []byte) (err error) {
+	return ch.DemandPaymentWithContext(context.Background(), channelAddress, value, nonce, sig)
+}
 
-		tx, err = channelInstance.ChannelTimeout(auth)
-		if err != nil {
-			retryCount++
-			log.Println("channelTimeout Err:", err)
-			if err.Error() == core.ErrNonceTooLow.Error() && auth.GasPrice.Cmp(big.NewInt(defaultGasPrice)) > 0 {
-				log.Println("previously pending transaction has successfully executed")
-				break
-			}
-			if retryCount > sendTransactionRetryCount {
-				return err
-			}
-			time.Sleep(retryTxSleepTime)
-			continue
-		}
+//DemandPaymentWithContext is DemandPayment which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DemandPaymentWithContext(ctx context.Context, channelAddress common.Address, value, nonce *big.Int, sig []byte) (err error) {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return err
+	}
 
-		err = checkTx(tx)
-		if err != nil {
-			checkRetryCount++
-			log.Println("channelTimeout transaction fails", err)
-			if checkRetryCount > checkTxRetryCount {
-				return err
-			}
-			continue
-		}
-		break
+	//nonce已被使用则不再发交易
+	used, err := ch.GetNonceValueWithContext(ctx, channelAddress, ch.addr, nonce)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrNonceUsed
 	}
 
-	log.Println("you have called ChannelTimeout successfully!")
-	return nil
+	hashNew := paymentHash(channelAddress, value, nonce, ch.addr)
+
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpPay, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.DemandPayment(auth, hashNew, value, nonce, sig)
+	})
+	return err
+}
+
+//GetNonceValue reports whether the voucher of nonce for recipient has been paid by the channel-contract
+func (ch *ChannelNodeInfo) GetNonceValue(channelAddress, recipient common.Address, nonce *big.Int) (bool, error) {
+	return ch.GetNonceValueWithContext(context.Background(), channelAddress, recipient, nonce)
 }
 
-//CloseChannel called by provider to stop the channel-contract,the ownerAddress implements the mapper
-func (ch *ChannelNodeInfo) CloseChannel(channelAddress common.Address, sig []byte, value *big.Int) (err error) {
-	channelInstance, err := channel.NewChannel(channelAddress, getClient(EndPoint))
+//GetNonceValueWithContext is GetNonceValue which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetNonceValueWithContext(ctx context.Context, channelAddress, recipient common.Address, nonce *big.Int) (bool, error) {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
 	if err != nil {
-		return err
+		return false, err
 	}
-	//(channelAddress, value)的哈希值
+
+	return channelInstance.GetNonceValue(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}, recipient, nonce)
+}
+
+//paymentHash (channelAddress, value, nonce, recipient)的哈希值, checked by DemandPayment
+func paymentHash(channelAddress common.Address, value, nonce *big.Int, recipient common.Address) [32]byte {
 	var hashNew [32]byte
 	valueNew := common.LeftPadBytes(value.Bytes(), 32)
-	hash := crypto.Keccak256(channelAddress.Bytes(), valueNew) //32Byte
+	nonceNew := common.LeftPadBytes(nonce.Bytes(), 32)
+	hash := crypto.Keccak256(channelAddress.Bytes(), valueNew, nonceNew, recipient.Bytes()) //32Byte
 	copy(hashNew[:], hash[:32])
+	return hashNew
+}
 
-	//用user的签名来触发closeChannel()
-	log.Println("begin call closeChannel...")
-	tx := &types.Transaction{}
-	retryCount := 0
-	checkRetryCount := 0
-	for {
-		auth, errMA := makeAuth(ch.hexSk, nil, nil, big.NewInt(defaultGasPrice), defaultGasLimit)
-		if errMA != nil {
-			return errMA
-		}
+//GetChannelBalance returns the money left in the channel-contract
+func (ch *ChannelNodeInfo) GetChannelBalance(channelAddress common.Address) (*big.Int, error) {
+	return ch.GetChannelBalanceWithContext(context.Background(), channelAddress)
+}
 
-		if err == ErrTxFail && tx != nil {
-			auth.Nonce = big.NewInt(int64(tx.Nonce()))
-			auth.GasPrice = new(big.Int).Add(tx.GasPrice(), big.NewInt(defaultGasPrice))
-			log.Println("rebuild transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice)
-		}
+//GetChannelBalanceWithContext is GetChannelBalance which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetChannelBalanceWithContext(ctx context.Context, channelAddress common.Address) (*big.Int, error) {
+	return ch.getBackend().BalanceAt(ctx, channelAddress, nil)
+}
 
-		tx, err = channelInstance.CloseChannel(auth, hashNew, value, sig)
-		if err != nil {
-			retryCount++
-			log.Println("closeChannel Err:", err)
-			if err.Error() == core.ErrNonceTooLow.Error() && auth.GasPrice.Cmp(big.NewInt(defaultGasPrice)) > 0 {
-				log.Println("previously pending transaction has successfully executed")
-				break
-			}
-			if retryCount > sendTransactionRetryCount {
-				return err
-			}
-			time.Sleep(retryTxSleepTime)
-			continue
-		}
+//TopUpChannel called by user to add amount to the channel-contract
+func (ch *ChannelNodeInfo) TopUpChannel(channelAddress common.Address, amount *big.Int) error {
+	return ch.TopUpChannelWithContext(context.Background(), channelAddress, amount)
+}
 
-		err = checkTx(tx)
-		if err != nil {
-			checkRetryCount++
-			log.Println("closeChannel transaction fails", err)
-			if checkRetryCount > checkTxRetryCount {
-				return err
-			}
-			continue
-		}
-		break
+//TopUpChannelWithContext is TopUpChannel which is aborted when ctx is done
+func (ch *ChannelNodeInfo) TopUpChannelWithContext(ctx context.Context, channelAddress common.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrTopUpAmount
 	}
 
-	log.Println("you have called CloseChannel successfully!")
-	return nil
-}
-
-//ExtendChannelTime called by user to extend the time in channel contract
-func (ch *ChannelNodeInfo) ExtendChannelTime(channelAddress common.Address, addTime *big.Int) error {
-	channelInstance, err := channel.NewChannel(channelAddress, getClient(EndPoint))
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
 	if err != nil {
 		return err
 	}
 
-	log.Println("begin call extendChannelTime...")
-	tx := &types.Transaction{}
-	retryCount := 0
-	checkRetryCount := 0
-	for {
-		auth, errMA := makeAuth(ch.hexSk, nil, nil, big.NewInt(defaultGasPrice), defaultGasLimit)
-		if errMA != nil {
-			return errMA
-		}
-
-		if err == ErrTxFail && tx != nil {
-			auth.Nonce = big.NewInt(int64(tx.Nonce()))
-			auth.GasPrice = new(big.Int).Add(tx.GasPrice(), big.NewInt(defaultGasPrice))
-			log.Println("rebuild transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice)
-		}
+	//转账给合约，由receive()接收
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, amount, ch.policy, OpTopUp, channelInstance.Receive)
+	return err
+}
 
-		tx, err = channelInstance.Extend(auth, addTime)
-		if err != nil {
-			retryCount++
-			log.Println("extendChannelTime Err:", err)
-			if err.Error() == core.ErrNonceTooLow.Error() && auth.GasPrice.Cmp(big.NewInt(defaultGasPrice)) > 0 {
-				log.Println("previously pending transaction has successfully executed")
-				break
-			}
-			if retryCount > sendTransactionRetryCount {
-				return err
-			}
-			time.Sleep(retryTxSleepTime)
-			continue
-		}
+//ExtendChannelTime called by user to extend the time in channel contract
+func (ch *ChannelNodeInfo) ExtendChannelTime(channelAddress common.Address, addTime *big.Int) error {
+	return ch.ExtendChannelTimeWithContext(context.Background(), channelAddress, addTime)
+}
 
-		err = checkTx(tx)
-		if err != nil {
-			checkRetryCount++
-			log.Println("extendChannelTime transaction fails", err)
-			if checkRetryCount > checkTxRetryCount {
-				return err
-			}
-			continue
-		}
-		break
+//ExtendChannelTimeWithContext is ExtendChannelTime which is aborted when ctx is done
+func (ch *ChannelNodeInfo) ExtendChannelTimeWithContext(ctx context.Context, channelAddress common.Address, addTime *big.Int) error {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return err
 	}
 
-	log.Println("you have called extendChannelTime successfully!")
-	return nil
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpExtend, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.Extend(auth, addTime)
+	})
+	return err
 }
@@ -0,0 +1,722 @@
+package contracts_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gogo/protobuf/proto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/contracts/channel"
+	mpb "github.com/memoio/go-mefs/pb"
+	"github.com/memoio/go-mefs/role"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+// adminCode stands in for the adminOwned contract Channel.sol calls at adminAddr:
+// any call returns the banned version kept in slot 0, a call with exactly 32 bytes
+// of calldata stores them as the new banned version.
+const adminCode = "0x3660201460125760005460005260206000f35b60003560005500"
+
+// testTimeOut of the channels deployed by the tests: a simulated block is 10 seconds after its parent
+// and newTestChain mines one every 10ms, so an hour passes on chain in less than a second
+const testTimeOut = 365 * 24 * 3600
+
+var (
+	adminAddr = common.HexToAddress("0x8026796Fd7cE63EAe824314AA5bacF55643e893d")
+	ether     = big.NewInt(1000000000000000000)
+)
+
+type testChain struct {
+	t   *testing.T
+	sim *backends.SimulatedBackend
+	abi abi.ABI
+
+	payer, provider, other *ecdsa.PrivateKey
+}
+
+func newTestChain(t *testing.T) *testChain {
+	if len(common.FromHex(channel.ChannelBin)) == 0 {
+		t.Fatal("ChannelBin is empty, regenerate Channel.go from the compiled Channel.sol")
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(channel.ChannelABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := &testChain{t: t, abi: parsed}
+	alloc := core.GenesisAlloc{
+		adminAddr: {Code: common.FromHex(adminCode), Balance: new(big.Int)},
+	}
+	for _, key := range []**ecdsa.PrivateKey{&tc.payer, &tc.provider, &tc.other} {
+		*key, err = crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		alloc[crypto.PubkeyToAddress((*key).PublicKey)] = core.GenesisAccount{Balance: new(big.Int).Mul(big.NewInt(1000), ether)}
+	}
+	tc.sim = backends.NewSimulatedBackend(alloc, 100000000)
+
+	// mine continuously, SendTx waits for receipts like it does on a real chain
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				tc.sim.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+		tc.sim.Close()
+	})
+
+	return tc
+}
+
+func hexKey(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.FromECDSA(key))
+}
+
+func addr(key *ecdsa.PrivateKey) common.Address {
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// node returns a ChannelNodeInfo for key on the simulated chain which gives up on the first failure
+func (tc *testChain) node(key *ecdsa.PrivateKey, opts ...contracts.CHOption) *contracts.ChannelNodeInfo {
+	c, err := contracts.NewCH(addr(key), hexKey(key), append([]contracts.CHOption{contracts.WithBackend(tc.sim)}, opts...)...)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	ch := c.(*contracts.ChannelNodeInfo)
+	ch.SetTxPolicy(&contracts.TxPolicy{
+		RetrySleep: time.Millisecond,
+		GasPrice:   big.NewInt(1),
+		GasBump:    big.NewInt(1),
+		GasLimit:   5000000,
+		ChainID:    big.NewInt(1337), // chain ID of the simulated backend
+	})
+	return ch
+}
+
+func (tc *testChain) deploy(recipients []common.Address, timeOut int64, value *big.Int) common.Address {
+	auth := bind.NewKeyedTransactor(tc.payer)
+	auth.Value = value
+	auth.GasLimit = 5000000
+	_, tx, _, err := channel.DeployChannel(auth, tc.sim, addr(tc.payer), recipients, big.NewInt(timeOut), common.Address{})
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	channelAddr, err := bind.WaitDeployed(ctx, tc.sim, tx)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	return channelAddr
+}
+
+// voucher signs value and nonce for recipient with signer via role.SignForChannelPay
+func (tc *testChain) voucher(signer *ecdsa.PrivateKey, channelAddr, recipient common.Address, value, nonce int64) []byte {
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	mes, err := role.SignForChannelPay(channelID, hexKey(signer), recipient, big.NewInt(value), big.NewInt(nonce))
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	cSign := new(mpb.ChannelSign)
+	err = proto.Unmarshal(mes, cSign)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	if !role.VerifyChannelSign(cSign) {
+		tc.t.Fatal("voucher does not verify")
+	}
+	return cSign.GetSig()
+}
+
+func payHash(channelAddr, recipient common.Address, value, nonce int64) [32]byte {
+	var hash [32]byte
+	copy(hash[:], crypto.Keccak256(channelAddr.Bytes(),
+		common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(nonce).Bytes(), 32),
+		recipient.Bytes()))
+	return hash
+}
+
+// revert calls method of the contract at to (nil for deployment) as from and returns the revert error
+func (tc *testChain) revert(from common.Address, to *common.Address, data []byte) string {
+	_, err := tc.sim.CallContract(context.Background(), ethereum.CallMsg{
+		From: from,
+		To:   to,
+		Gas:  5000000,
+		Data: data,
+	}, nil)
+	if err == nil {
+		tc.t.Fatal("call does not revert")
+	}
+	return err.Error()
+}
+
+func (tc *testChain) pack(method string, args ...interface{}) []byte {
+	data, err := tc.abi.Pack(method, args...)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	return data
+}
+
+func (tc *testChain) setBannedVersion(version int64) {
+	nonce, err := tc.sim.PendingNonceAt(context.Background(), addr(tc.payer))
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	data := common.LeftPadBytes(big.NewInt(version).Bytes(), 32)
+	tx, err := types.SignTx(types.NewTransaction(nonce, adminAddr, new(big.Int), 100000, big.NewInt(1), data), types.HomesteadSigner{}, tc.payer)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	err = tc.sim.SendTransaction(context.Background(), tx)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = bind.WaitMined(ctx, tc.sim, tx)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+// skipTime mines a block d after the current one; AdjustTime alone only moves the pending block,
+// which the next transaction sent rebuilds
+func (tc *testChain) skipTime(d time.Duration) {
+	err := tc.sim.AdjustTime(d)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	tc.sim.Commit()
+}
+
+func (tc *testChain) balance(account common.Address) *big.Int {
+	bal, err := tc.sim.BalanceAt(context.Background(), account, nil)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	return bal
+}
+
+func TestChannelLifecycle(t *testing.T) {
+	tc := newTestChain(t)
+	payer := tc.node(tc.payer)
+	provider := tc.node(tc.provider)
+
+	deposit := new(big.Int).Mul(big.NewInt(10), ether)
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, deposit)
+	deployedAt := tc.sim.Blockchain().CurrentBlock().NumberU64()
+	if tc.balance(channelAddr).Cmp(deposit) != 0 {
+		t.Fatal("deposit is not in the channel")
+	}
+
+	// top up
+	err := payer.TopUpChannel(channelAddr, ether)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit = new(big.Int).Add(deposit, ether)
+	bal, err := payer.GetChannelBalance(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bal.Cmp(deposit) != 0 {
+		t.Fatal("top-up is not in the channel:", bal)
+	}
+
+	start, timeOut, sender, recipients, err := payer.GetChannelInfo(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeOut != testTimeOut || sender != addr(tc.payer) || len(recipients) != 1 || recipients[0] != addr(tc.provider) {
+		t.Fatal("wrong channel info", start, timeOut, sender.String(), recipients)
+	}
+
+	state, err := payer.GetChannelState(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.StatusAt(state.ChainTime, time.Minute) != contracts.ChannelOpen || state.Owner != addr(tc.payer) || state.Balance.Cmp(deposit) != 0 ||
+		state.Expiry.Unix() != start+timeOut || !state.IsRecipient(addr(tc.provider)) || state.Version != contracts.ChannelVersion {
+		t.Fatalf("wrong channel state %+v", state)
+	}
+
+	// redeem a voucher
+	sig := tc.voucher(tc.payer, channelAddr, addr(tc.provider), ether.Int64(), 1)
+	err = provider.DemandPayment(channelAddr, ether, big.NewInt(1), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.balance(channelAddr).Cmp(new(big.Int).Sub(deposit, ether)) != 0 {
+		t.Fatal("voucher is not paid")
+	}
+
+	// the same nonce is refused before sending
+	err = provider.DemandPayment(channelAddr, ether, big.NewInt(1), sig)
+	if err != contracts.ErrNonceUsed {
+		t.Fatal("used nonce is not refused:", err)
+	}
+
+	// extend
+	err = payer.ExtendChannelTime(channelAddr, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, timeOut, _, _, err = payer.GetChannelInfo(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeOut != testTimeOut+100 {
+		t.Fatal("timeout is not extended:", timeOut)
+	}
+
+	// time out
+	err = payer.ChannelTimeout(channelAddr)
+	if !errors.Is(err, contracts.ErrTimeNotUp) {
+		t.Fatal("channel times out too early:", err)
+	}
+
+	tc.skipTime((testTimeOut + 101) * time.Second)
+
+	before := tc.balance(addr(tc.payer))
+	err = payer.ChannelTimeout(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := tc.sim.CodeAt(context.Background(), channelAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 0 {
+		t.Fatal("channel is not destroyed")
+	}
+	state, err = payer.GetChannelState(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != contracts.ChannelDestroyed || state.Block != tc.sim.Blockchain().CurrentBlock().NumberU64() {
+		t.Fatal("destroyed channel is", state.Status)
+	}
+	// its close event tells it from a channel never deployed
+	state, err = payer.GetChannelStateSince(channelAddr, deployedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != contracts.ChannelDestroyed {
+		t.Fatal("destroyed channel is", state.Status)
+	}
+	if tc.balance(addr(tc.payer)).Cmp(before) <= 0 {
+		t.Fatal("remaining deposit is not returned")
+	}
+}
+
+func TestDemandPaymentReverts(t *testing.T) {
+	tc := newTestChain(t)
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, ether)
+
+	sig := tc.voucher(tc.payer, channelAddr, addr(tc.provider), 1000, 1)
+	err := tc.node(tc.provider).DemandPayment(channelAddr, big.NewInt(1000), big.NewInt(1), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		from   common.Address
+		hash   [32]byte
+		value  int64
+		nonce  int64
+		sig    []byte
+		reason string
+	}{
+		{
+			name:   "not a recipient",
+			from:   addr(tc.other),
+			hash:   payHash(channelAddr, addr(tc.other), 1000, 2),
+			value:  1000,
+			nonce:  2,
+			sig:    tc.voucher(tc.payer, channelAddr, addr(tc.other), 1000, 2),
+			reason: "illegal caller",
+		},
+		{
+			name:   "used nonce",
+			from:   addr(tc.provider),
+			hash:   payHash(channelAddr, addr(tc.provider), 1000, 1),
+			value:  1000,
+			nonce:  1,
+			sig:    sig,
+			reason: "illegal nonce",
+		},
+		{
+			name:   "hash of another value",
+			from:   addr(tc.provider),
+			hash:   payHash(channelAddr, addr(tc.provider), 1000, 2),
+			value:  2000,
+			nonce:  2,
+			sig:    tc.voucher(tc.payer, channelAddr, addr(tc.provider), 1000, 2),
+			reason: "illegal hash",
+		},
+		{
+			name:   "not signed by the sender",
+			from:   addr(tc.provider),
+			hash:   payHash(channelAddr, addr(tc.provider), 1000, 2),
+			value:  1000,
+			nonce:  2,
+			sig:    tc.voucher(tc.other, channelAddr, addr(tc.provider), 1000, 2),
+			reason: "illegal sig",
+		},
+	}
+
+	for _, test := range tests {
+		data := tc.pack("DemandPayment", test.hash, big.NewInt(test.value), big.NewInt(test.nonce), test.sig)
+		got := tc.revert(test.from, &channelAddr, data)
+		if !strings.Contains(got, test.reason) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.reason)
+		}
+	}
+}
+
+func TestDemandPayments(t *testing.T) {
+	tc := newTestChain(t)
+	provider := tc.node(tc.provider)
+
+	var payments []contracts.Payment
+	for i := 0; i < 3; i++ {
+		channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, ether)
+		payments = append(payments, contracts.Payment{
+			Channel: channelAddr,
+			Value:   big.NewInt(1000),
+			Nonce:   big.NewInt(1),
+			Sig:     tc.voucher(tc.payer, channelAddr, addr(tc.provider), 1000, 1),
+		})
+	}
+	// signed by someone else
+	payments[1].Sig = tc.voucher(tc.other, payments[1].Channel, addr(tc.provider), 1000, 1)
+	// redeemed twice in the batch
+	payments = append(payments, payments[2])
+
+	results := provider.DemandPayments(payments)
+	if len(results) != len(payments) {
+		t.Fatalf("got %d results, want %d", len(results), len(payments))
+	}
+	want := []error{nil, contracts.ErrIllegalSig, nil, contracts.ErrNonceUsed}
+	for i, r := range results {
+		if !errors.Is(r.Err, want[i]) {
+			t.Errorf("payment %d: got %v, want %v", i, r.Err, want[i])
+		}
+	}
+	for _, i := range []int{0, 2} {
+		if tc.balance(payments[i].Channel).Cmp(new(big.Int).Sub(ether, big.NewInt(1000))) != 0 {
+			t.Errorf("payment %d is not paid", i)
+		}
+	}
+}
+
+func TestVoucherLedgerRedeemed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "voucher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tc := newTestChain(t)
+	payer := tc.node(tc.payer)
+	provider := tc.node(tc.provider)
+
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, big.NewInt(100))
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := role.OpenVoucherLedger(dir, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	signer := contracts.NewECDSASigner(tc.payer)
+
+	v, err := l.SignVoucher(channelID, signer, addr(tc.provider), big.NewInt(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := v.Sig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.DemandPayment(channelAddr, v.Value, v.Nonce, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 40 is left and nothing is owed: the redeemed voucher is not counted against the balance again
+	_, err = l.SignVoucher(channelID, signer, addr(tc.provider), big.NewInt(40))
+	if err != nil {
+		t.Fatal("voucher within the balance is refused:", err)
+	}
+	_, err = l.SignVoucher(channelID, signer, addr(tc.provider), big.NewInt(1))
+	if err != role.ErrVoucherOverdraw {
+		t.Fatalf("got %v, want %v", err, role.ErrVoucherOverdraw)
+	}
+}
+
+func TestSettlePayment(t *testing.T) {
+	tc := newTestChain(t)
+	provider := tc.node(tc.provider)
+
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, ether)
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := func(value int64) []byte {
+		mes, err := role.SignForChannelTotal(channelID, hexKey(tc.payer), addr(tc.provider), big.NewInt(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cSign := new(mpb.ChannelSign)
+		err = proto.Unmarshal(mes, cSign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !role.VerifyChannelTotalSign(cSign) {
+			t.Fatal("voucher does not verify")
+		}
+		return cSign.GetSig()
+	}
+
+	// each settlement pays the difference from what is withdrawn
+	for _, value := range []int64{100, 300} {
+		err = provider.SettlePayment(channelAddr, big.NewInt(value), total(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.balance(channelAddr).Cmp(new(big.Int).Sub(ether, big.NewInt(value))) != 0 {
+			t.Fatalf("total %d is not paid", value)
+		}
+	}
+	withdrawn, err := provider.GetWithdrawn(channelAddr, addr(tc.provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawn.Int64() != 300 {
+		t.Fatalf("got withdrawn %s, want 300", withdrawn)
+	}
+
+	err = provider.SettlePayment(channelAddr, big.NewInt(200), total(200))
+	if err != contracts.ErrTotalWithdrawn {
+		t.Fatalf("got %v, want %v", err, contracts.ErrTotalWithdrawn)
+	}
+}
+
+func TestChannelTimeoutReverts(t *testing.T) {
+	tc := newTestChain(t)
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, ether)
+
+	got := tc.revert(addr(tc.payer), &channelAddr, tc.pack("ChannelTimeout"))
+	if !strings.Contains(got, "Time is not up") {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDeployReverts(t *testing.T) {
+	tc := newTestChain(t)
+	bin := common.FromHex(channel.ChannelBin)
+
+	got := tc.revert(addr(tc.payer), nil, append(bin, tc.pack("", addr(tc.payer), []common.Address{addr(tc.provider)}, big.NewInt(0), common.Address{})...))
+	if !strings.Contains(got, "execution reverted") {
+		t.Fatalf("zero timeout: got %q", got)
+	}
+
+	got = tc.revert(addr(tc.payer), nil, append(bin, tc.pack("", addr(tc.other), []common.Address{addr(tc.provider)}, big.NewInt(3600), common.Address{})...))
+	if !strings.Contains(got, "illegal sender") {
+		t.Fatalf("another sender: got %q", got)
+	}
+
+	tc.setBannedVersion(int64(contracts.ChannelVersion))
+	got = tc.revert(addr(tc.payer), nil, append(bin, tc.pack("", addr(tc.payer), []common.Address{addr(tc.provider)}, big.NewInt(3600), common.Address{})...))
+	if !strings.Contains(got, "deploy channel is banned") {
+		t.Fatalf("banned: got %q", got)
+	}
+}
+
+func TestDeployChannelByFactory(t *testing.T) {
+	tc := newTestChain(t)
+	recipients := []common.Address{addr(tc.provider)}
+
+	_, err := tc.node(tc.payer).DeployChannelByFactory(recipients, big.NewInt(3600), big.NewInt(0), ether)
+	if err != contracts.ErrNoFactory {
+		t.Fatalf("got %v, want %v", err, contracts.ErrNoFactory)
+	}
+
+	// anyone may deploy the factory, the channels record the one deploying them
+	factory, err := tc.node(tc.other).DeployChannelFactory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := tc.node(tc.payer, contracts.WithChannelFactory(factory))
+	channelAddr, err := payer.DeployChannelByFactory(recipients, big.NewInt(3600), big.NewInt(0), ether)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := contracts.ComputeChannelAddress(factory, addr(tc.payer), recipients, big.NewInt(3600), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channelAddr != want {
+		t.Fatal("channel is not at the computed address")
+	}
+	if tc.balance(channelAddr).Cmp(ether) != 0 {
+		t.Fatal("deposit is not in the channel")
+	}
+
+	_, _, sender, _, err := payer.GetChannelInfo(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != addr(tc.payer) {
+		t.Fatal("channel sender is", sender.String())
+	}
+	channelInstance, err := channel.NewChannel(channelAddr, tc.sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := channelInstance.GetOwner(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != addr(tc.payer) {
+		t.Fatal("channel owner is", owner.String())
+	}
+	channelFactory, err := channelInstance.GetFactory(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channelFactory != factory {
+		t.Fatal("channel factory is", channelFactory.String())
+	}
+
+	_, err = payer.DeployChannelByFactory(recipients, big.NewInt(3600), big.NewInt(0), ether)
+	if err != contracts.ErrChannelExists {
+		t.Fatalf("got %v, want %v", err, contracts.ErrChannelExists)
+	}
+}
+
+// memMapper keeps the mappers in memory, on the same simulated chain as far as the tests are concerned
+type memMapper struct {
+	channels map[string][]common.Address
+}
+
+func (m *memMapper) AddChannel(ctx context.Context, owner common.Address, key string, channelAddr common.Address) error {
+	m.channels[owner.String()+key] = append(m.channels[owner.String()+key], channelAddr)
+	return nil
+}
+
+func (m *memMapper) GetChannels(ctx context.Context, owner common.Address, key string) ([]common.Address, error) {
+	return m.channels[owner.String()+key], nil
+}
+
+func (m *memMapper) GetLatestChannel(ctx context.Context, owner common.Address, key string) (common.Address, error) {
+	channels := m.channels[owner.String()+key]
+	if len(channels) == 0 {
+		return common.Address{}, errors.New("no channel in mapper")
+	}
+	return channels[len(channels)-1], nil
+}
+
+func TestDeployMultiChannelContract(t *testing.T) {
+	tc := newTestChain(t)
+	query := common.HexToAddress("0x1000")
+	recipients := []common.Address{addr(tc.provider), addr(tc.other), addr(tc.provider)}
+
+	// a backend of its own has no mapper at EndPoint to fall back to
+	_, _, err := tc.node(tc.payer).DeployMultiChannelContract(query, recipients, big.NewInt(testTimeOut), ether)
+	if err != contracts.ErrNoMapper {
+		t.Fatalf("got %v, want %v", err, contracts.ErrNoMapper)
+	}
+
+	mapper := &memMapper{channels: make(map[string][]common.Address)}
+	payer := tc.node(tc.payer, contracts.WithMapper(mapper))
+	channelAddr, unique, err := payer.DeployMultiChannelContract(query, recipients, big.NewInt(testTimeOut), ether)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unique) != 2 {
+		t.Fatal("repeated recipients are kept:", unique)
+	}
+
+	for _, recipient := range unique {
+		channels, err := payer.GetChannelAddrs(addr(tc.payer), recipient, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(channels) != 1 || channels[0] != channelAddr {
+			t.Fatalf("mapper of %s has %v", recipient.String(), channels)
+		}
+		latest, _, err := payer.GetLatestChannel(addr(tc.payer), recipient, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latest != channelAddr {
+			t.Fatalf("latest channel of %s is %s", recipient.String(), latest.String())
+		}
+	}
+}
+
+func TestExtendReverts(t *testing.T) {
+	tc := newTestChain(t)
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, ether)
+
+	got := tc.revert(addr(tc.payer), &channelAddr, tc.pack("Extend", big.NewInt(0)))
+	if !strings.Contains(got, "execution reverted") {
+		t.Fatalf("zero addTime: got %q", got)
+	}
+
+	tc.setBannedVersion(int64(contracts.ChannelVersion))
+	got = tc.revert(addr(tc.payer), &channelAddr, tc.pack("Extend", big.NewInt(100)))
+	if !strings.Contains(got, "extend is banned") {
+		t.Fatalf("banned: got %q", got)
+	}
+}
+
+func TestNewCHBadKey(t *testing.T) {
+	if _, err := contracts.NewCH(common.HexToAddress("0x1000"), "not a key"); err == nil {
+		t.Fatal("NewCH takes a bad hex key")
+	}
+}
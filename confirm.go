@@ -0,0 +1,110 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+//names of the channel operations given to SendTx, Confirmations are looked up by them
+const (
+	OpDeploy  = "deployChannel"
+	OpPay     = "demandPayment"
+	OpSettle  = "settlePayment" //waited for as Pay
+	OpTimeout = "channelTimeout"
+	OpExtend  = "extendChannelTime"
+	OpTopUp   = "topUpChannel"
+	OpApprove = "approveToken" //not waited for, the deposit after it cannot be mined before it
+	OpDeposit = "depositToken" //waited for as TopUp
+)
+
+//ErrTxReorged the transaction is no longer on chain after a reorg and does not come back
+var ErrTxReorged = errors.New("transaction is reorganized out of the chain")
+
+//Confirmations blocks mined on top of the receipt before an operation returns
+type Confirmations struct {
+	Deploy  uint64 //also before the channel is added to the mapper
+	Pay     uint64
+	Timeout uint64
+	Extend  uint64
+	TopUp   uint64
+}
+
+//DefaultConfirmations the depths used by DefaultTxPolicy
+var DefaultConfirmations = Confirmations{
+	Deploy:  6,
+	Pay:     2,
+	Timeout: 2,
+	Extend:  2,
+	TopUp:   2,
+}
+
+//depth returns the confirmations of the operation name, 0 for the others
+func (c Confirmations) depth(name string) uint64 {
+	switch name {
+	case OpDeploy:
+		return c.Deploy
+	case OpPay, OpSettle:
+		return c.Pay
+	case OpTimeout:
+		return c.Timeout
+	case OpExtend:
+		return c.Extend
+	case OpTopUp, OpDeposit:
+		return c.TopUp
+	default:
+		return 0
+	}
+}
+
+//ReorgFunc is called when the receipt of a transaction of operation name is gone from its block
+type ReorgFunc func(name string, receipt *types.Receipt)
+
+//confirmTx waits until depth blocks are mined on top of receipt;
+//if the receipt leaves its block onReorg is called and the transaction is waited for again,
+//ErrTxReorged is returned if it is not back within depth blocks
+func confirmTx(ctx context.Context, backend ChannelBackend, receipt *types.Receipt, depth uint64, name string, onReorg ReorgFunc) (*types.Receipt, error) {
+	var lostAt uint64 //block number when the receipt is found missing, 0 if it is there
+	for {
+		head, err := backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return receipt, err
+		}
+		headNum := head.Number.Uint64()
+
+		current, err := backend.TransactionReceipt(ctx, receipt.TxHash)
+		if err != nil && err != ethereum.NotFound {
+			return receipt, err
+		}
+		if current == nil || current.BlockHash != receipt.BlockHash {
+			if lostAt == 0 {
+				log.Println(name, "transaction", receipt.TxHash.Hex(), "leaves block", receipt.BlockNumber, "in a reorg")
+				if onReorg != nil {
+					onReorg(name, receipt)
+				}
+				lostAt = headNum
+			}
+			if current != nil {
+				//重新打包进了新区块，从新区块开始计数
+				receipt = current
+				lostAt = 0
+				if receipt.Status != types.ReceiptStatusSuccessful {
+					return receipt, ErrTxFail
+				}
+				continue
+			}
+			if headNum > lostAt+depth {
+				return receipt, ErrTxReorged
+			}
+		} else if headNum >= receipt.BlockNumber.Uint64()+depth {
+			return receipt, nil
+		}
+
+		if !sleepWithContext(ctx, waitReceiptInterval) {
+			return receipt, ctx.Err()
+		}
+	}
+}
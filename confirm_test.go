@@ -0,0 +1,137 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// reorgBackend pretends the receipts of the simulated chain were dropped or moved by a reorg.
+type reorgBackend struct {
+	*backends.SimulatedBackend
+	lk    sync.Mutex
+	gone  bool           // receipts are not found
+	moved *types.Receipt // returned instead of the real receipt
+}
+
+func (b *reorgBackend) set(gone bool, moved *types.Receipt) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	b.gone, b.moved = gone, moved
+}
+
+func (b *reorgBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.lk.Lock()
+	gone, moved := b.gone, b.moved
+	b.lk.Unlock()
+	if gone {
+		return nil, ethereum.NotFound
+	}
+	if moved != nil {
+		return moved, nil
+	}
+	return b.SimulatedBackend.TransactionReceipt(ctx, txHash)
+}
+
+func TestConfirmTx(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(sk.PublicKey)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from: {Balance: big.NewInt(1000000000000000000)},
+	}, 100000000)
+	defer sim.Close()
+	backend := &reorgBackend{SimulatedBackend: sim}
+	ctx := context.Background()
+
+	tx, err := types.SignTx(types.NewTransaction(0, common.HexToAddress("0x2000"), big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sim.SendTransaction(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mine in the background so that confirmTx sees the head move
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sim.Commit()
+			}
+		}
+	}()
+
+	var reorgs []string
+	onReorg := func(name string, r *types.Receipt) {
+		reorgs = append(reorgs, name)
+	}
+
+	got, err := confirmTx(ctx, backend, receipt, 3, OpPay, onReorg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := sim.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Number.Uint64() < receipt.BlockNumber.Uint64()+3 {
+		t.Fatalf("confirmed at block %d, receipt in block %d", head.Number, receipt.BlockNumber)
+	}
+	if got.BlockHash != receipt.BlockHash || len(reorgs) != 0 {
+		t.Fatalf("receipt changes without a reorg: %v", reorgs)
+	}
+
+	// moved into a later block: waited for again from there
+	moved := *receipt
+	moved.BlockHash = common.HexToHash("0x1")
+	moved.BlockNumber = new(big.Int).Set(head.Number)
+	backend.set(false, &moved)
+	got, err = confirmTx(ctx, backend, receipt, 1, OpDeploy, onReorg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BlockHash != moved.BlockHash {
+		t.Fatalf("got receipt in block %s, want %s", got.BlockHash.Hex(), moved.BlockHash.Hex())
+	}
+	if len(reorgs) != 1 || reorgs[0] != OpDeploy {
+		t.Fatalf("got reorgs %v, want [%s]", reorgs, OpDeploy)
+	}
+
+	// gone for good
+	backend.set(true, nil)
+	_, err = confirmTx(ctx, backend, receipt, 1, OpTopUp, onReorg)
+	if err != ErrTxReorged {
+		t.Fatalf("got %v, want %v", err, ErrTxReorged)
+	}
+	if len(reorgs) != 2 || reorgs[1] != OpTopUp {
+		t.Fatalf("got reorgs %v, want [%s %s]", reorgs, OpDeploy, OpTopUp)
+	}
+
+	if DefaultConfirmations.depth(OpDeploy) != 6 || DefaultConfirmations.depth("unknown") != 0 {
+		t.Fatal("wrong depths of DefaultConfirmations")
+	}
+}
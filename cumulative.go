@@ -0,0 +1,76 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+//ErrTotalWithdrawn the total of the cumulative voucher has already been withdrawn from the channel-contract
+var ErrTotalWithdrawn = errors.New("voucher total has been withdrawn")
+
+//GetWithdrawn returns what the channel-contract has paid to recipient by cumulative vouchers
+func (ch *ChannelNodeInfo) GetWithdrawn(channelAddress, recipient common.Address) (*big.Int, error) {
+	return ch.GetWithdrawnWithContext(context.Background(), channelAddress, recipient)
+}
+
+//GetWithdrawnWithContext is GetWithdrawn which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetWithdrawnWithContext(ctx context.Context, channelAddress, recipient common.Address) (*big.Int, error) {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return nil, err
+	}
+
+	return channelInstance.GetWithdrawn(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}, recipient)
+}
+
+//SettlePayment called by provider with the latest cumulative voucher,
+//the channel-contract pays total minus what it has paid to the provider before
+func (ch *ChannelNodeInfo) SettlePayment(channelAddress common.Address, total *big.Int, sig []byte) error {
+	return ch.SettlePaymentWithContext(context.Background(), channelAddress, total, sig)
+}
+
+//SettlePaymentWithContext is SettlePayment which is aborted when ctx is done
+func (ch *ChannelNodeInfo) SettlePaymentWithContext(ctx context.Context, channelAddress common.Address, total *big.Int, sig []byte) error {
+	channelInstance, err := channel.NewChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return err
+	}
+
+	//已提取的不少于total则不再发交易
+	withdrawn, err := channelInstance.GetWithdrawn(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}, ch.addr)
+	if err != nil {
+		return err
+	}
+	if withdrawn.Cmp(total) >= 0 {
+		return ErrTotalWithdrawn
+	}
+
+	hashNew := totalPaymentHash(channelAddress, total, ch.addr)
+
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpSettle, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.SettlePayment(auth, hashNew, total, sig)
+	})
+	return err
+}
+
+//totalPaymentHash (channelAddress, total, recipient)的哈希值, checked by SettlePayment
+func totalPaymentHash(channelAddress common.Address, total *big.Int, recipient common.Address) [32]byte {
+	var hashNew [32]byte
+	totalNew := common.LeftPadBytes(total.Bytes(), 32)
+	hash := crypto.Keccak256(channelAddress.Bytes(), totalNew, recipient.Bytes()) //32Byte
+	copy(hashNew[:], hash[:32])
+	return hashNew
+}
@@ -0,0 +1,189 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+//dynamicFeeTxType the EIP-2718 type of an EIP-1559 transaction
+const dynamicFeeTxType = 0x02
+
+//errDynamicSent stops a TxBuilder once its transaction is sent as a DynamicFeeTx instead
+var errDynamicSent = errors.New("sent as a dynamic-fee transaction")
+
+//errNoChainID a dynamic-fee transaction is always signed for a chain
+var errNoChainID = errors.New("chain ID is unknown")
+
+//RPCCaller raw JSON-RPC access, met by rpc.Client;
+//SendTx sends EIP-1559 transactions through a backend which meets it, see NewRPCBackend
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+//rpcBackend ethclient.Client which also makes raw calls on its connection
+type rpcBackend struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+//NewRPCBackend the backend of node c for WithBackend, transactions through it are dynamic-fee ones
+//once the chain has a base fee; go-ethereum v1.9 ethclient.Client does not give its connection away
+func NewRPCBackend(c *rpc.Client) ChannelBackend {
+	return &rpcBackend{Client: ethclient.NewClient(c), rpc: c}
+}
+
+func (b *rpcBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return b.rpc.CallContext(ctx, result, method, args...)
+}
+
+//DynamicFeeTx an EIP-1559 transaction, go-ethereum v1.9 types.Transaction is legacy only
+type DynamicFeeTx struct {
+	ChainID   *big.Int
+	Nonce     uint64
+	GasTipCap *big.Int //maxPriorityFeePerGas
+	GasFeeCap *big.Int //maxFeePerGas
+	Gas       uint64
+	To        *common.Address //nil means contract creation
+	Value     *big.Int
+	Data      []byte
+
+	V, R, S *big.Int //signature, V is 0 or 1
+}
+
+//newDynamicFeeTx the dynamic-fee form of the legacy transaction tx
+func newDynamicFeeTx(chainID *big.Int, tx *types.Transaction, tip, feeCap *big.Int) *DynamicFeeTx {
+	return &DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     tx.Nonce(),
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       tx.Gas(),
+		To:        tx.To(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}
+}
+
+//fields in the order of the RLP payload, without the signature
+func (tx *DynamicFeeTx) fields() []interface{} {
+	//access list is always empty
+	return []interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, []interface{}{}}
+}
+
+func typedRLP(fields []interface{}) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{dynamicFeeTxType}, payload...), nil
+}
+
+//SigHash returns the hash signed by the sender
+func (tx *DynamicFeeTx) SigHash() (common.Hash, error) {
+	b, err := typedRLP(tx.fields())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(b), nil
+}
+
+//MarshalBinary returns the signed transaction as eth_sendRawTransaction takes it
+func (tx *DynamicFeeTx) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	return typedRLP(append(tx.fields(), tx.V, tx.R, tx.S))
+}
+
+//Hash returns the hash of the signed transaction, which its receipt is looked up by
+func (tx *DynamicFeeTx) Hash() (common.Hash, error) {
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(b), nil
+}
+
+//Sign signs tx by signer
+func (tx *DynamicFeeTx) Sign(signer Signer) error {
+	h, err := tx.SigHash()
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignHash(h[:])
+	if err != nil {
+		return err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return errors.New("wrong signature length")
+	}
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = new(big.Int).SetBytes(sig[64:])
+	return nil
+}
+
+//dynamicFeeChain returns the caller and chain ID to send dynamic-fee transactions through backend,
+//a nil caller if backend does not meet RPCCaller or its chain has no base fee yet
+func dynamicFeeChain(ctx context.Context, backend ChannelBackend, policy *TxPolicy) (RPCCaller, *big.Int, error) {
+	//动态费用交易需要原始RPC，且链上已有base fee
+	caller, ok := backend.(RPCCaller)
+	if !ok {
+		return nil, nil, nil
+	}
+	base, err := baseFee(ctx, caller)
+	if err != nil {
+		return nil, nil, err
+	}
+	if base == nil {
+		return nil, nil, nil
+	}
+
+	id, err := chainID(ctx, backend, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if id == nil {
+		return nil, nil, errNoChainID
+	}
+	return caller, id, nil
+}
+
+//dynamicFeeAuth makes auth send the transaction bind builds as a DynamicFeeTx paying tip and feeCap;
+//the TxBuilder then returns errDynamicSent, sent gets the legacy form made by bind and the transaction sent
+func dynamicFeeAuth(auth *bind.TransactOpts, caller RPCCaller, signer Signer, chainID, tip, feeCap *big.Int, sent func(legacy *types.Transaction, tx *DynamicFeeTx)) {
+	auth.Signer = func(_ types.Signer, addr common.Address, legacy *types.Transaction) (*types.Transaction, error) {
+		if addr != signer.Address() {
+			return nil, ErrWrongSigner
+		}
+		tx := newDynamicFeeTx(chainID, legacy, tip, feeCap)
+		err := tx.Sign(signer)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		ctx := auth.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		err = caller.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(raw))
+		if err != nil {
+			return nil, err
+		}
+		sent(legacy, tx)
+		return nil, errDynamicSent
+	}
+}
@@ -0,0 +1,178 @@
+package contracts
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestDynamicFeeTxSign(t *testing.T) {
+	sk, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x2000")
+	tx := &DynamicFeeTx{
+		ChainID:   big.NewInt(5),
+		Nonce:     7,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(22),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(3),
+		Data:      []byte{1, 2},
+	}
+	err = tx.Sign(NewECDSASigner(sk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// made by types.SignTx of go-ethereum v1.10+ with the London signer
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "02f8640507021682520894000000000000000000000000000000000000200003820102c001a093fdf5ddaa5a774fc76ecf55dae8e42dc4731d32d5d9a4512d6601e3b74c1e25a0314817f43b28d1dddbd925304576f5c8787625ee355e338c923b905546914194"
+	if hex.EncodeToString(raw) != want {
+		t.Fatalf("got %x, want %s", raw, want)
+	}
+	hash, err := tx.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != common.HexToHash("0x16622dddc325a824c3af776cbe80072ef0948ffbbab653bf90adccd225ffa1c1") {
+		t.Fatal("wrong hash:", hash.Hex())
+	}
+}
+
+// feeBackend a node after London: it takes raw dynamic-fee transactions but mines none of them
+type feeBackend struct {
+	*dropBackend
+	baseFee *big.Int // nil for a chain before London
+	tip     *big.Int
+	raw     []*DynamicFeeTx
+}
+
+// decodedTx a DynamicFeeTx with its access list, as it is sent
+type decodedTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList []struct {
+		Address common.Address
+		Keys    []common.Hash
+	}
+	V, R, S *big.Int
+}
+
+func (b *feeBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var res interface{}
+	switch method {
+	case "eth_getBlockByNumber":
+		res = map[string]*hexutil.Big{"baseFeePerGas": (*hexutil.Big)(b.baseFee)}
+	case "eth_maxPriorityFeePerGas":
+		res = (*hexutil.Big)(b.tip)
+	case "eth_sendRawTransaction":
+		raw := args[0].(hexutil.Bytes)
+		var d decodedTx
+		if raw[0] != dynamicFeeTxType {
+			return rlp.ErrExpectedList
+		}
+		err := rlp.DecodeBytes(raw[1:], &d)
+		if err != nil {
+			return err
+		}
+		b.raw = append(b.raw, &DynamicFeeTx{d.ChainID, d.Nonce, d.GasTipCap, d.GasFeeCap, d.Gas, d.To, d.Value, d.Data, d.V, d.R, d.S})
+		return nil
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func TestSendTxDynamicFee(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &feeBackend{dropBackend: newDropBackend(t, sk, 0), baseFee: big.NewInt(10), tip: big.NewInt(2)}
+	to := common.HexToAddress("0x2000")
+
+	policy := testPolicy()
+	policy.GasPrice = nil
+	res, err := SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, to))
+	if err != ErrTxNotMined {
+		t.Fatalf("got %v, want %v", err, ErrTxNotMined)
+	}
+	if res.Attempts != 3 || len(backend.raw) != 3 || len(backend.sent) != 0 {
+		t.Fatal("transactions are not sent as dynamic-fee ones:", res.Attempts, len(backend.raw), len(backend.sent))
+	}
+
+	// tip from the node, fee cap twice the base fee plus the tip, both bumped by 10% at least
+	fees := [][2]int64{{2, 22}, {3, 25}, {4, 28}}
+	for i, tx := range backend.raw {
+		if tx.Nonce != 0 || *tx.To != to || tx.ChainID.Int64() != 1337 {
+			t.Fatalf("attempt %d is not a replacement", i)
+		}
+		if tx.GasTipCap.Int64() != fees[i][0] || tx.GasFeeCap.Int64() != fees[i][1] {
+			t.Fatalf("attempt %d pays tip %s, fee cap %s, want %v", i, tx.GasTipCap, tx.GasFeeCap, fees[i])
+		}
+		h, err := tx.SigHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := append(append(common.LeftPadBytes(tx.R.Bytes(), 32), common.LeftPadBytes(tx.S.Bytes(), 32)...), byte(tx.V.Uint64()))
+		pub, err := crypto.SigToPub(h[:], sig)
+		if err != nil || crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(sk.PublicKey) {
+			t.Fatalf("attempt %d is not signed by the sender", i)
+		}
+	}
+	last, err := backend.raw[2].Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TxHash != last || res.GasTipCap.Int64() != 4 || res.GasPrice.Int64() != 28 {
+		t.Fatal("result is not of the last transaction sent")
+	}
+
+	// the fee cap of a replacement may not go beyond MaxFeePerGas
+	backend.raw = nil
+	policy.MaxFeePerGas = big.NewInt(26)
+	_, err = SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, to))
+	if err != ErrGasPriceCap || len(backend.raw) != 2 {
+		t.Fatalf("got %v after %d attempts, want %v after 2", err, len(backend.raw), ErrGasPriceCap)
+	}
+}
+
+func TestSendTxBeforeLondon(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &feeBackend{dropBackend: newDropBackend(t, sk, 0)}
+
+	policy := testPolicy()
+	policy.MineTimeout = 2 * time.Second
+	res, err := SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, common.HexToAddress("0x2000")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.raw) != 0 || len(backend.sent) != 1 || res.GasTipCap != nil {
+		t.Fatal("transaction is not a legacy one without a base fee")
+	}
+}
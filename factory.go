@@ -0,0 +1,140 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+//ErrNoFactory the instance is not given a ChannelFactory-contract, see WithChannelFactory
+var ErrNoFactory = errors.New("no channel factory is set")
+
+//ErrNoChannelBin the channel bytecode is not filled in the binding, so the CREATE2 address cannot be computed
+var ErrNoChannelBin = errors.New("channel bytecode is not in the binding")
+
+//ErrChannelExists a channel is already deployed at the address of the sequence
+var ErrChannelExists = errors.New("channel of the sequence has been deployed")
+
+//ChannelSalt the CREATE2 salt ChannelFactory uses, keccak256(abi.encodePacked(sender, recipients, sequence))
+func ChannelSalt(sender common.Address, recipients []common.Address, sequence *big.Int) [32]byte {
+	var salt [32]byte
+	//encodePacked中数组元素仍补齐到32字节
+	data := make([]byte, 0, common.AddressLength+32*(len(recipients)+1))
+	data = append(data, sender.Bytes()...)
+	for _, recipient := range recipients {
+		data = append(data, common.LeftPadBytes(recipient.Bytes(), 32)...)
+	}
+	data = append(data, common.LeftPadBytes(sequence.Bytes(), 32)...)
+	copy(salt[:], crypto.Keccak256(data))
+	return salt
+}
+
+//ComputeChannelAddress returns the address the ChannelFactory at factory deploys the channel of sender to,
+//without asking the chain; vouchers can be signed for it before the deployment is mined
+func ComputeChannelAddress(factory, sender common.Address, recipients []common.Address, timeOut, sequence *big.Int) (common.Address, error) {
+	if channel.ChannelBin == "" {
+		return common.Address{}, ErrNoChannelBin
+	}
+
+	args, err := channelArgs(sender, recipients, timeOut, factory)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	initCode := append(common.FromHex(channel.ChannelBin), args...)
+	return crypto.CreateAddress2(factory, ChannelSalt(sender, recipients, sequence), crypto.Keccak256(initCode)), nil
+}
+
+//channelArgs the constructor arguments of Channel appended to its bytecode,
+//factory is the ChannelFactory deploying it, the zero address for a direct deployment
+func channelArgs(sender common.Address, recipients []common.Address, timeOut *big.Int, factory common.Address) ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(channel.ChannelABI))
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Pack("", sender, recipients, timeOut, factory)
+}
+
+//DeployChannelFactory deploy a ChannelFactory-contract, give its address to the instances by WithChannelFactory
+func (ch *ChannelNodeInfo) DeployChannelFactory() (common.Address, error) {
+	return ch.DeployChannelFactoryWithContext(context.Background())
+}
+
+//DeployChannelFactoryWithContext is DeployChannelFactory which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DeployChannelFactoryWithContext(ctx context.Context) (common.Address, error) {
+	var factory common.Address
+
+	client := ch.getBackend()
+	res, err := SendTxWithContext(ctx, client, ch.signer, nil, ch.policy, OpDeploy, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		fAddr, tx, _, err := channel.DeployChannelFactory(auth, client)
+		if fAddr.String() != InvalidAddr {
+			factory = fAddr
+		}
+		return tx, err
+	})
+	if err != nil {
+		return factory, err
+	}
+	//a dynamic-fee deploy does not give bind the address
+	if res.Receipt != nil && res.Receipt.ContractAddress.String() != InvalidAddr {
+		factory = res.Receipt.ContractAddress
+	}
+
+	log.Println("channel factory contract", factory.String(), "has been successfuly deployed!")
+	return factory, nil
+}
+
+//DeployChannelByFactory deploy a channel-contract paying recipients by the ChannelFactory given by WithChannelFactory;
+//its address comes from (factory, sender, recipients, timeOut, sequence), so it is not written to the mapper
+func (ch *ChannelNodeInfo) DeployChannelByFactory(recipients []common.Address, timeOut, sequence, moneyToChannel *big.Int) (common.Address, error) {
+	return ch.DeployChannelByFactoryWithContext(context.Background(), recipients, timeOut, sequence, moneyToChannel)
+}
+
+//DeployChannelByFactoryWithContext is DeployChannelByFactory which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DeployChannelByFactoryWithContext(ctx context.Context, recipients []common.Address, timeOut, sequence, moneyToChannel *big.Int) (common.Address, error) {
+	if len(recipients) == 0 {
+		return common.Address{}, ErrNoRecipient
+	}
+	factory := ch.factory
+	if factory == (common.Address{}) {
+		return common.Address{}, ErrNoFactory
+	}
+
+	channelAddr, err := ComputeChannelAddress(factory, ch.addr, recipients, timeOut, sequence)
+	if err != nil {
+		return channelAddr, err
+	}
+
+	backend := ch.getBackend()
+	code, err := backend.CodeAt(ctx, channelAddr, nil)
+	if err != nil {
+		return channelAddr, err
+	}
+	if len(code) > 0 {
+		return channelAddr, ErrChannelExists
+	}
+
+	factoryInstance, err := channel.NewChannelFactory(factory, backend)
+	if err != nil {
+		return channelAddr, err
+	}
+
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, moneyToChannel, ch.policy, OpDeploy, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return factoryInstance.CreateChannel(auth, recipients, timeOut, sequence)
+	})
+	if err != nil {
+		return channelAddr, err
+	}
+
+	log.Println("channel contract", channelAddr.String(), "with", recipients, "have been successfuly deployed by factory", factory.String())
+	return channelAddr, nil
+}
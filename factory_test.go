@@ -0,0 +1,103 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+// create2Code is the runtime of a stub factory: CREATE2 of calldata[32:] with salt calldata[:32] and msg.value,
+// returning the new address.
+var create2Code = hexutil.MustDecode("0x366020900380602060003760003590600034f560005260206000f3")
+
+func TestComputeChannelAddress(t *testing.T) {
+	sender := common.HexToAddress("0x1000")
+	recipients := []common.Address{common.HexToAddress("0x2000"), common.HexToAddress("0x3000")}
+	factory := common.HexToAddress("0x4000")
+	timeOut := big.NewInt(3600)
+
+	bin := channel.ChannelBin
+	defer func() { channel.ChannelBin = bin }()
+
+	channel.ChannelBin = ""
+	_, err := ComputeChannelAddress(factory, sender, recipients, timeOut, big.NewInt(0))
+	if err != ErrNoChannelBin {
+		t.Fatalf("got %v, want %v", err, ErrNoChannelBin)
+	}
+
+	// init code of a stub channel whose runtime is a single zero byte
+	channel.ChannelBin = "0x60016000f3"
+
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(sk.PublicKey)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from:    {Balance: big.NewInt(1000000000000000000)},
+		factory: {Balance: new(big.Int), Code: create2Code},
+	}, 100000000)
+	defer sim.Close()
+	ctx := context.Background()
+
+	seen := make(map[common.Address]bool)
+	for nonce, sequence := range []int64{0, 1} {
+		channelAddr, err := ComputeChannelAddress(factory, sender, recipients, timeOut, big.NewInt(sequence))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[channelAddr] {
+			t.Fatalf("sequence %d gives a used address %s", sequence, channelAddr.Hex())
+		}
+		seen[channelAddr] = true
+
+		// deploy the same init code the way ChannelFactory.CreateChannel does
+		salt := ChannelSalt(sender, recipients, big.NewInt(sequence))
+		args, err := channelArgs(sender, recipients, timeOut, factory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := append(salt[:], common.FromHex(channel.ChannelBin)...)
+		data = append(data, args...)
+		tx, err := types.SignTx(types.NewTransaction(uint64(nonce), factory, big.NewInt(5), 200000, big.NewInt(1), data), types.HomesteadSigner{}, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sim.SendTransaction(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Commit()
+
+		code, err := sim.CodeAt(ctx, channelAddr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) == 0 {
+			t.Fatalf("no channel deployed at the computed address %s", channelAddr.Hex())
+		}
+		balance, err := sim.BalanceAt(ctx, channelAddr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Int64() != 5 {
+			t.Fatalf("got balance %s, want 5", balance)
+		}
+	}
+
+	other, err := ComputeChannelAddress(factory, sender, recipients[:1], timeOut, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen[other] {
+		t.Fatal("recipients are not in the address")
+	}
+}
@@ -0,0 +1,184 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+//replacePriceBump the least raise in percent of the gas price the node takes for a replacement,
+//the default price bump of the geth txpool
+const replacePriceBump = 10
+
+//ErrGasPriceCap the gas price would have to go beyond TxPolicy.MaxGasPrice
+var ErrGasPriceCap = errors.New("gas price reaches the cap of the policy")
+
+//errDryRun stops a TxBuilder once the transaction is made, before it is signed and sent
+var errDryRun = errors.New("dry run")
+
+//gasPrice returns the gas price of a new transaction: policy.GasPrice if set,
+//otherwise the price suggested by the node, no more than policy.MaxGasPrice
+func gasPrice(ctx context.Context, backend bind.ContractTransactor, policy *TxPolicy) (*big.Int, error) {
+	if policy.GasPrice != nil {
+		return capGasPrice(policy.GasPrice, policy), nil
+	}
+
+	price, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return capGasPrice(price, policy), nil
+}
+
+//bumpGasPrice returns the gas price to replace a transaction of price old:
+//old plus policy.GasBump, and at least replacePriceBump percent more so that the node takes it
+func bumpGasPrice(old *big.Int, policy *TxPolicy) (*big.Int, error) {
+	price := bumpFee(old, policy)
+	if policy.MaxGasPrice != nil && price.Cmp(policy.MaxGasPrice) > 0 {
+		return nil, ErrGasPriceCap
+	}
+	return price, nil
+}
+
+//bumpFee returns old plus policy.GasBump, and at least replacePriceBump percent more
+func bumpFee(old *big.Int, policy *TxPolicy) *big.Int {
+	least := new(big.Int).Mul(old, big.NewInt(100+replacePriceBump))
+	least.Div(least, big.NewInt(100))
+	least.Add(least, big.NewInt(1))
+
+	fee := new(big.Int).Set(old)
+	if policy.GasBump != nil {
+		fee.Add(fee, policy.GasBump)
+	}
+	if fee.Cmp(least) < 0 {
+		fee = least
+	}
+	return fee
+}
+
+//baseFee returns the base fee of the latest block, nil if the chain has none yet (before London)
+func baseFee(ctx context.Context, caller RPCCaller) (*big.Int, error) {
+	//v1.9的types.Header没有baseFee，直接读区块
+	var head struct {
+		BaseFee *hexutil.Big `json:"baseFeePerGas"`
+	}
+	err := caller.CallContext(ctx, &head, "eth_getBlockByNumber", "latest", false)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil
+	}
+	return head.BaseFee.ToInt(), nil
+}
+
+//suggestGasTipCap returns the tip the node suggests for a dynamic-fee transaction to be mined in time
+func suggestGasTipCap(ctx context.Context, caller RPCCaller) (*big.Int, error) {
+	var tip hexutil.Big
+	err := caller.CallContext(ctx, &tip, "eth_maxPriorityFeePerGas")
+	if err != nil {
+		return nil, err
+	}
+	return tip.ToInt(), nil
+}
+
+//gasFees returns the tip and fee cap of a new dynamic-fee transaction on a chain of base fee base:
+//the tip is policy.GasTipCap if set, otherwise the one suggested by the node;
+//the fee cap is twice the base fee plus the tip, so that it stays above the base fee for some full blocks,
+//no more than policy.MaxFeePerGas and the tip no more than the fee cap
+func gasFees(ctx context.Context, caller RPCCaller, base *big.Int, policy *TxPolicy) (*big.Int, *big.Int, error) {
+	tip := policy.GasTipCap
+	if tip == nil {
+		var err error
+		tip, err = suggestGasTipCap(ctx, caller)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	feeCap := new(big.Int).Mul(base, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+	if policy.MaxFeePerGas != nil && feeCap.Cmp(policy.MaxFeePerGas) > 0 {
+		feeCap = new(big.Int).Set(policy.MaxFeePerGas)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	return tip, feeCap, nil
+}
+
+//bumpGasFees returns the tip and fee cap to replace a dynamic-fee transaction of oldTip and oldFeeCap
+//on a chain of base fee base: both are raised as bumpGasPrice does, at least replacePriceBump percent
+//as the node wants of a replacement, and the fee cap stays twice the base fee plus the tip
+func bumpGasFees(oldTip, oldFeeCap, base *big.Int, policy *TxPolicy) (*big.Int, *big.Int, error) {
+	tip := bumpFee(oldTip, policy)
+	feeCap := bumpFee(oldFeeCap, policy)
+	if least := new(big.Int).Add(new(big.Int).Mul(base, big.NewInt(2)), tip); feeCap.Cmp(least) < 0 {
+		feeCap = least
+	}
+
+	if policy.MaxFeePerGas != nil && feeCap.Cmp(policy.MaxFeePerGas) > 0 {
+		return nil, nil, ErrGasPriceCap
+	}
+	return tip, feeCap, nil
+}
+
+func capGasPrice(price *big.Int, policy *TxPolicy) *big.Int {
+	if policy.MaxGasPrice != nil && price.Cmp(policy.MaxGasPrice) > 0 {
+		return new(big.Int).Set(policy.MaxGasPrice)
+	}
+	return price
+}
+
+//gasLimit returns the gas limit of a new transaction built by build:
+//the gas estimated by backend times policy.GasMultiplier, or policy.GasLimit if the estimation fails;
+//the error of the revert is returned if the estimation says the transaction reverts
+func gasLimit(ctx context.Context, backend bind.ContractTransactor, from common.Address, value, gasPrice *big.Int, policy *TxPolicy, name string, build TxBuilder) (uint64, error) {
+	if policy.GasMultiplier <= 0 {
+		return policy.GasLimit, nil
+	}
+
+	//只构造交易，不签名也不发送
+	var tx *types.Transaction
+	_, err := build(&bind.TransactOpts{
+		From:     from,
+		Nonce:    new(big.Int),
+		Value:    value,
+		GasPrice: gasPrice,
+		GasLimit: 1, //not 0, which lets bind estimate by itself
+		Context:  ctx,
+		Signer: func(_ types.Signer, _ common.Address, t *types.Transaction) (*types.Transaction, error) {
+			tx = t
+			return nil, errDryRun
+		},
+	})
+	if tx == nil {
+		log.Println(name+" cannot be built for gas estimation, use the default gas limit:", err)
+		return policy.GasLimit, nil
+	}
+
+	gas, err := backend.EstimateGas(ctx, ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		GasPrice: gasPrice,
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	})
+	if err != nil {
+		if errRevert := decodeRevert(err); errRevert != nil {
+			return 0, fmt.Errorf("%s would revert: %w", name, errRevert)
+		}
+		log.Println(name+" estimate gas fails, use the default gas limit:", err)
+		return policy.GasLimit, nil
+	}
+
+	return uint64(float64(gas) * policy.GasMultiplier), nil
+}
@@ -0,0 +1,102 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBumpGasPrice(t *testing.T) {
+	tests := []struct {
+		old, bump, max int64
+		want           int64
+		err            error
+	}{
+		{100, 0, 0, 111, nil},   // at least 10% more
+		{100, 50, 0, 150, nil},  // the policy bump when it is larger
+		{100, 0, 111, 111, nil}, // up to the cap
+		{100, 0, 110, 0, ErrGasPriceCap},
+		{1, 0, 0, 2, nil},
+	}
+
+	for _, test := range tests {
+		policy := &TxPolicy{GasBump: big.NewInt(test.bump)}
+		if test.max > 0 {
+			policy.MaxGasPrice = big.NewInt(test.max)
+		}
+		got, err := bumpGasPrice(big.NewInt(test.old), policy)
+		if err != test.err {
+			t.Errorf("bumpGasPrice(%d) fails with %v, want %v", test.old, err, test.err)
+			continue
+		}
+		if err == nil && got.Int64() != test.want {
+			t.Errorf("bumpGasPrice(%d) = %d, want %d", test.old, got, test.want)
+		}
+	}
+}
+
+// failingEstimator is a node which cannot estimate gas
+type failingEstimator struct {
+	*backends.SimulatedBackend
+}
+
+func (failingEstimator) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 0, errors.New("method not available")
+}
+
+func TestGasLimit(t *testing.T) {
+	from := common.HexToAddress("0x1000")
+	storeAddr := common.HexToAddress("0x2000")  // stores calldata in slot 0
+	revertAddr := common.HexToAddress("0x3000") // always reverts
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from:       {Balance: big.NewInt(1000000000000000000)},
+		storeAddr:  {Code: common.FromHex("0x60003560005500"), Balance: new(big.Int)},
+		revertAddr: {Code: common.FromHex("0x60006000fd"), Balance: new(big.Int)},
+	}, 100000000)
+	defer sim.Close()
+
+	build := func(to common.Address) TxBuilder {
+		return func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return bind.NewBoundContract(to, abi.ABI{}, sim, sim, sim).RawTransact(auth, common.LeftPadBytes([]byte{1}, 32))
+		}
+	}
+	policy := &TxPolicy{GasLimit: 3000000, GasMultiplier: 1.5}
+	ctx := context.Background()
+
+	want, err := sim.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &storeAddr, GasPrice: big.NewInt(1), Data: common.LeftPadBytes([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gas, err := gasLimit(ctx, sim, from, nil, big.NewInt(1), policy, "store", build(storeAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != uint64(float64(want)*1.5) {
+		t.Fatalf("got gas limit %d, want %d", gas, uint64(float64(want)*1.5))
+	}
+
+	_, err = gasLimit(ctx, sim, from, nil, big.NewInt(1), policy, "revert", build(revertAddr))
+	if !errors.Is(err, ErrReverted) {
+		t.Fatal("revert is not reported:", err)
+	}
+
+	gas, err = gasLimit(ctx, failingEstimator{sim}, from, nil, big.NewInt(1), policy, "store", build(storeAddr))
+	if err != nil || gas != policy.GasLimit {
+		t.Fatal("does not fall back to the default gas limit:", gas, err)
+	}
+
+	policy.GasMultiplier = 0
+	gas, err = gasLimit(ctx, sim, from, nil, big.NewInt(1), policy, "revert", build(revertAddr))
+	if err != nil || gas != policy.GasLimit {
+		t.Fatal("estimates without a multiplier:", gas, err)
+	}
+}
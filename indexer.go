@@ -0,0 +1,589 @@
+package contracts
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/memoio/go-mefs/contracts/channel"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//types of ChannelEvent
+const (
+	EventPay        = "channelPay"
+	EventAlterOwner = "AlterOwner"
+	EventDestroy    = "destroy" //the channel-contract is gone, ChannelTimeout selfdestructs it
+
+	eventClose = "closeChannel" //logged by ChannelTimeout before it selfdestructs, from version 2 on
+)
+
+const (
+	defaultReorgDepth    = 12               //recent blocks re-checked for reorgs on every sync
+	defaultIndexInterval = 15 * time.Second //time between two syncs in Run
+	maxFilterRange       = 5000             //blocks asked for in one FilterLogs
+
+	indexChannelPrefix = "channel/" //channel/<addr> -> last block indexed for it, unscanned for a new channel
+	indexEventPrefix   = "event/"   //event/<addr>/<block><log index> -> ChannelEvent
+	indexHashPrefix    = "hash/"    //hash/<block> -> hash of a recent block indexed
+	indexCodePrefix    = "code/"    //code/<addr> -> block the code of the channel is first seen at
+	destroyLogIndex    = ^uint32(0) //log index in the key of an EventDestroy without log, after all logs of its block
+	unscanned          = ^uint64(0) //height of a channel added but not scanned yet
+)
+
+//ChannelEvent one event in the history of a channel-contract
+type ChannelEvent struct {
+	Channel     common.Address `json:"channel"`
+	Type        string         `json:"type"`
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"txHash"` //empty for EventDestroy of a version 1 channel, which logs nothing
+	Index       uint           `json:"index"`  //log index in the block
+	From        common.Address `json:"from"`   //payer of EventPay, old owner of EventAlterOwner, sender of EventDestroy
+	To          common.Address `json:"to"`     //recipient of EventPay, new owner of EventAlterOwner
+	Value       *big.Int       `json:"value"`  //paid by EventPay, returned to the sender by EventDestroy; in the token of a TokenChannel
+}
+
+type mapperKey struct {
+	user, provider, query common.Address
+}
+
+//ChannelIndexer scans and follows the events of channel-contracts and keeps them on disk;
+//a TokenChannel logs the same events as a Channel, only its values are in its token
+type ChannelIndexer struct {
+	lk      sync.Mutex //guards mappers and the channel list, never held during a chain read
+	syncLk  sync.Mutex //one Sync at a time
+	db      *leveldb.DB
+	ch      *ChannelNodeInfo
+	mappers []mapperKey
+
+	ReorgDepth uint64        //recent blocks re-checked for reorgs on every sync
+	Interval   time.Duration //time between two syncs in Run
+	StartBlock uint64        //first block scanned for a new channel, such as the block the channels are first deployed at;
+	//0 means a new channel is followed from the head of the sync which adds it, its earlier events are not indexed
+
+	payTopic, ownerTopic, closeTopic common.Hash
+}
+
+//OpenChannelIndexer opens or creates the index stored in dir, ch is used to read the chain and the mappers
+func OpenChannelIndexer(dir string, ch *ChannelNodeInfo) (*ChannelIndexer, error) {
+	parsed, err := abi.JSON(strings.NewReader(channel.ChannelABI))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChannelIndexer{
+		db:         db,
+		ch:         ch,
+		ReorgDepth: defaultReorgDepth,
+		Interval:   defaultIndexInterval,
+		payTopic:   parsed.Events[EventPay].ID,
+		ownerTopic: parsed.Events[EventAlterOwner].ID,
+		closeTopic: parsed.Events[eventClose].ID,
+	}, nil
+}
+
+//Close closes the underlying store
+func (ix *ChannelIndexer) Close() error {
+	return ix.db.Close()
+}
+
+//WatchMapper adds the channels in the mapper of user, provider and query on every sync
+func (ix *ChannelIndexer) WatchMapper(userAddress, providerAddress, queryAddress common.Address) {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	ix.mappers = append(ix.mappers, mapperKey{userAddress, providerAddress, queryAddress})
+}
+
+//AddChannel indexes the channels from StartBlock on, or from the head of the next sync
+func (ix *ChannelIndexer) AddChannel(channelAddrs ...common.Address) error {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	for _, channelAddr := range channelAddrs {
+		err := ix.addChannel(channelAddr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ix *ChannelIndexer) addChannel(channelAddr common.Address) error {
+	key := []byte(indexChannelPrefix + channelAddr.Hex())
+	has, err := ix.db.Has(key, nil)
+	if err != nil || has {
+		return err
+	}
+	return ix.db.Put(key, uint64Bytes(unscanned), nil)
+}
+
+//Channels returns the channels indexed
+func (ix *ChannelIndexer) Channels() ([]common.Address, error) {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	heights, err := ix.heights()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]common.Address, 0, len(heights))
+	for channelAddr := range heights {
+		res = append(res, channelAddr)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Hex() < res[j].Hex()
+	})
+	return res, nil
+}
+
+//Run syncs every Interval until ctx is done
+func (ix *ChannelIndexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(ix.Interval)
+	defer ticker.Stop()
+	for {
+		err := ix.Sync(ctx)
+		if err != nil {
+			log.Println("sync channel events fails:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Sync indexes the events of all channels up to the latest block;
+//events of recent blocks which have been reorganized away are dropped and scanned again
+func (ix *ChannelIndexer) Sync(ctx context.Context) error {
+	//链上读取不持ix.lk，History等不必等待同步
+	ix.syncLk.Lock()
+	defer ix.syncLk.Unlock()
+
+	ix.lk.Lock()
+	mappers := append([]mapperKey(nil), ix.mappers...)
+	ix.lk.Unlock()
+
+	var found []common.Address
+	for _, m := range mappers {
+		addrs, err := ix.ch.GetChannelAddrsWithContext(ctx, m.user, m.provider, m.query)
+		if err != nil {
+			return err
+		}
+		found = append(found, addrs...)
+	}
+
+	err := ix.AddChannel(found...)
+	if err != nil {
+		return err
+	}
+
+	backend := ix.ch.getBackend()
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	headNum := head.Number.Uint64()
+
+	err = ix.rewind(ctx, headNum)
+	if err != nil {
+		return err
+	}
+
+	ix.lk.Lock()
+	heights, err := ix.heights()
+	ix.lk.Unlock()
+	if err != nil {
+		return err
+	}
+	next := make(map[common.Address]uint64, len(heights))
+	for channelAddr, height := range heights {
+		from := height + 1
+		if height == unscanned {
+			//没有StartBlock时新通道只从当前区块开始跟踪，避免从创世区块扫描
+			from = ix.StartBlock
+			if from == 0 {
+				from = headNum
+			}
+		} else if from < ix.StartBlock {
+			from = ix.StartBlock
+		}
+		if from <= headNum {
+			next[channelAddr] = from
+		}
+	}
+	err = ix.scan(ctx, next, head)
+	if err != nil {
+		return err
+	}
+
+	return ix.saveHashes(ctx, headNum, head)
+}
+
+//rewind finds the first recent block whose hash has changed,
+//drops the events from it on and lets the channels be scanned again from there
+func (ix *ChannelIndexer) rewind(ctx context.Context, headNum uint64) error {
+	backend := ix.ch.getBackend()
+
+	forkAt := uint64(0)
+	iter := ix.db.NewIterator(util.BytesPrefix([]byte(indexHashPrefix)), nil)
+	for iter.Next() {
+		num := binary.BigEndian.Uint64(iter.Key()[len(indexHashPrefix):])
+		if num > headNum {
+			forkAt = num
+			break
+		}
+		header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(num))
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		if header.Hash() != common.BytesToHash(iter.Value()) {
+			forkAt = num
+			break
+		}
+	}
+	iter.Release()
+	if iter.Error() != nil {
+		return iter.Error()
+	}
+	if forkAt == 0 {
+		return nil
+	}
+
+	log.Println("chain reorganized from block", forkAt, "rescan channel events")
+
+	batch := new(leveldb.Batch)
+	iter = ix.db.NewIterator(util.BytesPrefix([]byte(indexEventPrefix)), nil)
+	for iter.Next() {
+		key := iter.Key()
+		num := binary.BigEndian.Uint64(key[len(key)-12 : len(key)-4])
+		if num >= forkAt {
+			batch.Delete(append([]byte(nil), key...))
+		}
+	}
+	iter.Release()
+	if iter.Error() != nil {
+		return iter.Error()
+	}
+
+	iter = ix.db.NewIterator(util.BytesPrefix([]byte(indexHashPrefix)), nil)
+	for iter.Next() {
+		num := binary.BigEndian.Uint64(iter.Key()[len(indexHashPrefix):])
+		if num >= forkAt {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+	}
+	iter.Release()
+	if iter.Error() != nil {
+		return iter.Error()
+	}
+
+	//the code seen in a block reorganized away may not be there any more
+	iter = ix.db.NewIterator(util.BytesPrefix([]byte(indexCodePrefix)), nil)
+	for iter.Next() {
+		if binary.BigEndian.Uint64(iter.Value()) >= forkAt {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+	}
+	iter.Release()
+	if iter.Error() != nil {
+		return iter.Error()
+	}
+
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	heights, err := ix.heights()
+	if err != nil {
+		return err
+	}
+	for channelAddr, height := range heights {
+		if height != unscanned && height >= forkAt {
+			batch.Put([]byte(indexChannelPrefix+channelAddr.Hex()), uint64Bytes(forkAt-1))
+		}
+	}
+
+	return ix.db.Write(batch, nil)
+}
+
+//scan indexes the events of the channels up to head, next holds the first block still to scan of each channel;
+//one FilterLogs asks for all channels due in a range of blocks
+func (ix *ChannelIndexer) scan(ctx context.Context, next map[common.Address]uint64, head *types.Header) error {
+	if len(next) == 0 {
+		return nil
+	}
+
+	backend := ix.ch.getBackend()
+	//解析日志只用到ABI，与合约地址无关
+	filterer, err := channel.NewChannelFilterer(common.Address{}, backend)
+	if err != nil {
+		return err
+	}
+
+	to := head.Number.Uint64()
+	from := to
+	for _, n := range next {
+		if n < from {
+			from = n
+		}
+	}
+
+	for start := from; start <= to; start += maxFilterRange {
+		end := start + maxFilterRange - 1
+		if end > to {
+			end = to
+		}
+
+		var addrs []common.Address
+		for channelAddr, n := range next {
+			if n <= end {
+				addrs = append(addrs, channelAddr)
+			}
+		}
+		if len(addrs) == 0 {
+			continue
+		}
+
+		logs, err := backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: addrs,
+			Topics:    [][]common.Hash{{ix.payTopic, ix.ownerTopic, ix.closeTopic}},
+		})
+		if err != nil {
+			return err
+		}
+
+		batch := new(leveldb.Batch)
+		for _, l := range logs {
+			//a channel scanned from a later block has these logs already
+			n, ok := next[l.Address]
+			if l.Removed || !ok || l.BlockNumber < n {
+				continue
+			}
+
+			ev := &ChannelEvent{
+				Channel:     l.Address,
+				BlockNumber: l.BlockNumber,
+				BlockHash:   l.BlockHash,
+				TxHash:      l.TxHash,
+				Index:       l.Index,
+			}
+			switch l.Topics[0] {
+			case ix.payTopic:
+				pay, err := filterer.ParseChannelPay(l)
+				if err != nil {
+					return err
+				}
+				ev.Type, ev.From, ev.To, ev.Value = EventPay, pay.From, pay.To, pay.Value
+			case ix.ownerTopic:
+				owner, err := filterer.ParseAlterOwner(l)
+				if err != nil {
+					return err
+				}
+				ev.Type, ev.From, ev.To = EventAlterOwner, owner.From, owner.To
+			case ix.closeTopic:
+				closed, err := filterer.ParseCloseChannel(l)
+				if err != nil {
+					return err
+				}
+				ev.Type, ev.From, ev.Value = EventDestroy, closed.Sender, closed.Value
+			default:
+				continue
+			}
+
+			err = putEvent(batch, ev, uint32(l.Index))
+			if err != nil {
+				return err
+			}
+		}
+		for _, channelAddr := range addrs {
+			batch.Put([]byte(indexChannelPrefix+channelAddr.Hex()), uint64Bytes(end))
+		}
+
+		err = ix.db.Write(batch, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for channelAddr := range next {
+		err = ix.checkDestroyed(ctx, channelAddr, head)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//checkDestroyed records EventDestroy at head once the code of the channel is gone, for a channel whose
+//ChannelTimeout logs nothing (version 1); the block is the one the indexer notices it at.
+//A channel whose code or events have never been seen is not deployed yet and is left alone
+func (ix *ChannelIndexer) checkDestroyed(ctx context.Context, channelAddr common.Address, head *types.Header) error {
+	last, err := ix.lastEvent(channelAddr)
+	if err != nil || (last != nil && last.Type == EventDestroy) {
+		return err
+	}
+
+	code, err := ix.ch.getBackend().CodeAt(ctx, channelAddr, head.Number)
+	if err != nil {
+		return err
+	}
+
+	codeKey := []byte(indexCodePrefix + channelAddr.Hex())
+	if len(code) != 0 {
+		has, err := ix.db.Has(codeKey, nil)
+		if err != nil || has {
+			return err
+		}
+		return ix.db.Put(codeKey, uint64Bytes(head.Number.Uint64()), nil)
+	}
+
+	//没有代码：从未见过代码也没有事件说明还未部署，不是被销毁
+	seen, err := ix.db.Has(codeKey, nil)
+	if err != nil {
+		return err
+	}
+	if !seen && last == nil {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	err = putEvent(batch, &ChannelEvent{
+		Channel:     channelAddr,
+		Type:        EventDestroy,
+		BlockNumber: head.Number.Uint64(),
+		BlockHash:   head.Hash(),
+	}, destroyLogIndex)
+	if err != nil {
+		return err
+	}
+	return ix.db.Write(batch, nil)
+}
+
+//lastEvent returns the last event indexed of the channel, nil if there is none
+func (ix *ChannelIndexer) lastEvent(channelAddr common.Address) (*ChannelEvent, error) {
+	iter := ix.db.NewIterator(util.BytesPrefix([]byte(indexEventPrefix+channelAddr.Hex()+"/")), nil)
+	defer iter.Release()
+	if !iter.Last() {
+		return nil, iter.Error()
+	}
+
+	ev := new(ChannelEvent)
+	err := json.Unmarshal(iter.Value(), ev)
+	if err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+//saveHashes keeps the hashes of the last ReorgDepth blocks for rewind, and drops older ones
+func (ix *ChannelIndexer) saveHashes(ctx context.Context, headNum uint64, head *types.Header) error {
+	backend := ix.ch.getBackend()
+
+	low := uint64(1)
+	if headNum > ix.ReorgDepth {
+		low = headNum - ix.ReorgDepth + 1
+	}
+
+	batch := new(leveldb.Batch)
+	iter := ix.db.NewIterator(util.BytesPrefix([]byte(indexHashPrefix)), nil)
+	for iter.Next() {
+		num := binary.BigEndian.Uint64(iter.Key()[len(indexHashPrefix):])
+		if num < low {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+	}
+	iter.Release()
+	if iter.Error() != nil {
+		return iter.Error()
+	}
+
+	for num := low; num <= headNum; num++ {
+		header := head
+		if num != headNum {
+			var err error
+			header, err = backend.HeaderByNumber(ctx, new(big.Int).SetUint64(num))
+			if err != nil {
+				return err
+			}
+		}
+		batch.Put(append([]byte(indexHashPrefix), uint64Bytes(num)...), header.Hash().Bytes())
+	}
+
+	return ix.db.Write(batch, nil)
+}
+
+//History returns the events of the channel indexed so far, in chain order
+func (ix *ChannelIndexer) History(channelAddr common.Address) ([]*ChannelEvent, error) {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	var evs []*ChannelEvent
+	iter := ix.db.NewIterator(util.BytesPrefix([]byte(indexEventPrefix+channelAddr.Hex()+"/")), nil)
+	defer iter.Release()
+	for iter.Next() {
+		ev := new(ChannelEvent)
+		err := json.Unmarshal(iter.Value(), ev)
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+
+	return evs, iter.Error()
+}
+
+//heights returns the last block indexed of every channel
+func (ix *ChannelIndexer) heights() (map[common.Address]uint64, error) {
+	heights := make(map[common.Address]uint64)
+	iter := ix.db.NewIterator(util.BytesPrefix([]byte(indexChannelPrefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		channelAddr := common.HexToAddress(string(iter.Key()[len(indexChannelPrefix):]))
+		heights[channelAddr] = binary.BigEndian.Uint64(iter.Value())
+	}
+	return heights, iter.Error()
+}
+
+//putEvent key is ordered by block and then log index
+func putEvent(batch *leveldb.Batch, ev *ChannelEvent, logIndex uint32) error {
+	val, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	key := []byte(indexEventPrefix + ev.Channel.Hex() + "/")
+	key = append(key, uint64Bytes(ev.BlockNumber)...)
+	index := make([]byte, 4)
+	binary.BigEndian.PutUint32(index, logIndex)
+	batch.Put(append(key, index...), val)
+	return nil
+}
+
+func uint64Bytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
@@ -0,0 +1,296 @@
+package contracts_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+)
+
+// eventCode stands in for a channel-contract: a call with calldata emits
+// channelPay(address(this), msg.sender, calldata[0:32]), a call without calldata
+// selfdestructs to msg.sender like ChannelTimeout.
+func eventCode() []byte {
+	topic := crypto.Keccak256Hash([]byte("channelPay(address,address,uint256)"))
+	code := []byte{0x36, 0x15, 0x60, 0x34, 0x57, 0x60, 0x00, 0x35, 0x60, 0x00, 0x52, 0x33, 0x30, 0x7f}
+	code = append(code, topic.Bytes()...)
+	return append(code, 0x60, 0x20, 0x60, 0x00, 0xa3, 0x00, 0x5b, 0x33, 0xff)
+}
+
+// forkedBackend pretends the blocks from forkAt on were replaced by a reorg
+// which dropped their logs.
+type forkedBackend struct {
+	*backends.SimulatedBackend
+	forkAt  uint64
+	filters int //FilterLogs calls
+}
+
+func (b *forkedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := b.SimulatedBackend.HeaderByNumber(ctx, number)
+	if err != nil || b.forkAt == 0 || header.Number.Uint64() < b.forkAt {
+		return header, err
+	}
+	header = types.CopyHeader(header)
+	header.Extra = []byte("fork")
+	return header, nil
+}
+
+func (b *forkedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	b.filters++
+	logs, err := b.SimulatedBackend.FilterLogs(ctx, query)
+	if err != nil || b.forkAt == 0 {
+		return logs, err
+	}
+	var res []types.Log
+	for _, l := range logs {
+		if l.BlockNumber < b.forkAt {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func TestChannelIndexer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	channelAddr := common.HexToAddress("0x1234")
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr(payer): {Balance: ether},
+		channelAddr: {Code: eventCode(), Balance: new(big.Int)},
+	}, 100000000)
+	defer sim.Close()
+	backend := &forkedBackend{SimulatedBackend: sim}
+
+	send := func(data []byte) uint64 {
+		nonce, err := sim.PendingNonceAt(context.Background(), addr(payer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := types.SignTx(types.NewTransaction(nonce, channelAddr, new(big.Int), 100000, big.NewInt(1), data), types.HomesteadSigner{}, payer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sim.SendTransaction(context.Background(), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim.Commit()
+		return sim.Blockchain().CurrentBlock().NumberU64()
+	}
+	pay := func(value int64) uint64 {
+		return send(common.LeftPadBytes(big.NewInt(value).Bytes(), 32))
+	}
+
+	c, err := contracts.NewCH(addr(payer), hexKey(payer), contracts.WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := c.(*contracts.ChannelNodeInfo)
+	ix, err := contracts.OpenChannelIndexer(dir, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.ReorgDepth = 5
+	ix.StartBlock = 1
+	// not deployed yet: no code is not a destroy
+	undeployed := common.HexToAddress("0x5678")
+	err = ix.AddChannel(channelAddr, undeployed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(values ...int64) []*contracts.ChannelEvent {
+		err := ix.Sync(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		evs, err := ix.History(channelAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(evs) < len(values) {
+			t.Fatalf("got %d events, want %d", len(evs), len(values))
+		}
+		for i, value := range values {
+			ev := evs[i]
+			if ev.Type != contracts.EventPay || ev.Value.Int64() != value || ev.To != addr(payer) {
+				t.Fatalf("wrong event %d: %+v", i, ev)
+			}
+		}
+		return evs
+	}
+
+	pay(1)
+	forkAt := pay(2)
+	if evs := check(1, 2); len(evs) != 2 {
+		t.Fatalf("got %d events, want 2", len(evs))
+	}
+	// one query for both channels
+	if backend.filters != 1 {
+		t.Fatalf("%d FilterLogs for one sync, want 1", backend.filters)
+	}
+
+	// the second payment is reorganized away
+	backend.forkAt = forkAt
+	if evs := check(1); len(evs) != 1 {
+		t.Fatalf("got %d events after reorg, want 1", len(evs))
+	}
+
+	// and comes back
+	backend.forkAt = 0
+	startAt := pay(3)
+	if evs := check(1, 2, 3); len(evs) != 3 {
+		t.Fatalf("got %d events, want 3", len(evs))
+	}
+	if evs, err := ix.History(undeployed); err != nil || len(evs) != 0 {
+		t.Fatalf("undeployed channel has events %+v, %v", evs, err)
+	}
+
+	// destroyed by time out
+	destroyAt := send(nil)
+	evs := check(1, 2, 3)
+	if len(evs) != 4 || evs[3].Type != contracts.EventDestroy || evs[3].BlockNumber != destroyAt {
+		t.Fatalf("destroy is not indexed: %+v", evs[len(evs)-1])
+	}
+
+	// everything survives a restart
+	err = ix.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix, err = contracts.OpenChannelIndexer(dir, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	if evs := check(1, 2, 3); len(evs) != 4 {
+		t.Fatalf("got %d events after restart, want 4", len(evs))
+	}
+
+	// a new indexer skips the blocks before StartBlock
+	dir2, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir2)
+	ix2, err := contracts.OpenChannelIndexer(dir2, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix2.Close()
+	ix2.StartBlock = startAt
+	err = ix2.AddChannel(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ix2.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs, err = ix2.History(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || evs[0].Value.Int64() != 3 || evs[1].Type != contracts.EventDestroy {
+		t.Fatalf("got %d events from block %d, want the third payment and the destroy", len(evs), startAt)
+	}
+
+	// without StartBlock a new channel is followed from the head on
+	dir3, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir3)
+	ix3, err := contracts.OpenChannelIndexer(dir3, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix3.Close()
+	err = ix3.AddChannel(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ix3.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs, err = ix3.History(channelAddr)
+	if err != nil || len(evs) != 0 {
+		t.Fatalf("got %d events before the head, %v", len(evs), err)
+	}
+}
+
+func TestChannelIndexerClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tc := newTestChain(t)
+	value := big.NewInt(1000)
+	channelAddr := tc.deploy([]common.Address{addr(tc.provider)}, testTimeOut, value)
+	payer := tc.node(tc.payer)
+
+	ix, err := contracts.OpenChannelIndexer(dir, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	err = ix.AddChannel(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ix.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc.skipTime((testTimeOut + 1) * time.Second)
+	err = payer.ChannelTimeout(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ix.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ChannelTimeout of a version 2 channel logs the close, the destroy is indexed from it
+	evs, err := ix.History(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("got %d events, want 1", len(evs))
+	}
+	ev := evs[0]
+	if ev.Type != contracts.EventDestroy || ev.TxHash == (common.Hash{}) || ev.From != addr(tc.payer) || ev.Value.Cmp(value) != 0 {
+		t.Fatalf("close is not indexed from its log: %+v", ev)
+	}
+	receipt, err := tc.sim.TransactionReceipt(context.Background(), ev.TxHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.BlockNumber.Uint64() != ev.BlockNumber {
+		t.Fatalf("destroy is indexed at block %d, mined at %s", ev.BlockNumber, receipt.BlockNumber)
+	}
+}
@@ -0,0 +1,95 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//ErrNoMapper a channel node on its own backend has no mapper unless one is given by WithMapper
+var ErrNoMapper = errors.New("no channel mapper on the backend")
+
+//ChannelMapper keeps the channel-contracts of an owner under a key, the key is query+channelKey+provider;
+//it must live on the same chain as the backend of the channel node
+type ChannelMapper interface {
+	//AddChannel appends channelAddr to the mapper of key owned by owner, creating the mapper if there is none
+	AddChannel(ctx context.Context, owner common.Address, key string, channelAddr common.Address) error
+	//GetChannels returns the channels in the mapper of key owned by owner, in the order they were added
+	GetChannels(ctx context.Context, owner common.Address, key string) ([]common.Address, error)
+	//GetLatestChannel returns the channel added last to the mapper of key owned by owner
+	GetLatestChannel(ctx context.Context, owner common.Address, key string) (common.Address, error)
+}
+
+//cManageMapper the mapper-contracts of go-mefs on the node at EndPoint, reached by ContractManage
+type cManageMapper struct {
+	addr  common.Address //local address
+	hexSk string         //local privateKey, signs the mapper transactions
+}
+
+//newCManageMapper is the mapper of a channel node at EndPoint, nil without a hex key
+func newCManageMapper(addr common.Address, hexSk string) ChannelMapper {
+	if hexSk == "" {
+		return nil
+	}
+	return &cManageMapper{addr: addr, hexSk: hexSk}
+}
+
+func (m *cManageMapper) AddChannel(ctx context.Context, owner common.Address, key string, channelAddr common.Address) error {
+	return withContext(ctx, func() error {
+		ma := NewCManage(m.addr, m.hexSk)
+		_, mapperInstance, err := ma.GetMapperFromAdmin(owner, key, true)
+		if err != nil {
+			return err
+		}
+		return ma.AddToMapper(channelAddr, mapperInstance)
+	})
+}
+
+func (m *cManageMapper) GetChannels(ctx context.Context, owner common.Address, key string) ([]common.Address, error) {
+	var addrs []common.Address
+	err := withContext(ctx, func() error {
+		ma := NewCManage(m.addr, m.hexSk)
+		_, mapperInstance, err := ma.GetMapperFromAdmin(owner, key, false)
+		if err != nil {
+			return err
+		}
+		addrs, err = ma.GetAddressFromMapper(mapperInstance)
+		return err
+	})
+	return addrs, err
+}
+
+func (m *cManageMapper) GetLatestChannel(ctx context.Context, owner common.Address, key string) (common.Address, error) {
+	var channelAddr common.Address
+	err := withContext(ctx, func() error {
+		ma := NewCManage(m.addr, m.hexSk)
+		_, mapperInstance, err := ma.GetMapperFromAdmin(owner, key, false)
+		if err != nil {
+			return err
+		}
+		channelAddr, err = ma.GetLatestFromMapper(mapperInstance)
+		return err
+	})
+	return channelAddr, err
+}
+
+//withContext runs f and returns ctx.Err() once ctx is done, ContractManage does not take a ctx;
+//f goes on in the background then, a transaction it has sent may still be mined
+func withContext(ctx context.Context, f func() error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
@@ -0,0 +1,145 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//defaultNonces shared by the transactions of all instances using DefaultTxPolicy
+var defaultNonces = NewNonceManager()
+
+//NonceReader reads the next nonce of an account from chain, met by ChannelBackend
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+//chainIDReader is met by backends knowing their chain, such as ethclient.Client
+type chainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+//NonceManager hands out transaction nonces of local accounts,
+//so that goroutines sending from one account do not take the same nonce from the node;
+//an account is kept apart on each chain, a chain is told by its chain ID or else by its backend,
+//so that all clients of one chain share the nonces of an account
+type NonceManager struct {
+	lk       sync.Mutex
+	accounts map[nonceKey]*accountNonce
+}
+
+type nonceKey struct {
+	chain interface{}
+	addr  common.Address
+}
+
+type accountNonce struct {
+	sem   chan struct{} //held from Acquire until release
+	next  uint64
+	known bool //false means next is read from chain by the next Acquire
+}
+
+//NewNonceManager new a manager knowing no nonce yet
+func NewNonceManager() *NonceManager {
+	return &NonceManager{
+		accounts: make(map[nonceKey]*accountNonce),
+	}
+}
+
+//chain returns the key of the chain reader is on: its chain ID, the reader itself if it knows none
+func (m *NonceManager) chain(ctx context.Context, reader NonceReader) (interface{}, error) {
+	//按chain ID区分，不记录reader，每次新建的client不会留下条目
+	if cr, ok := reader.(chainIDReader); ok {
+		id, err := cr.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	}
+
+	//不可比较的backend不能作map的键，只能按类型区分
+	if !reflect.TypeOf(reader).Comparable() {
+		return fmt.Sprintf("%T", reader), nil
+	}
+	return reader, nil
+}
+
+func (m *NonceManager) account(ctx context.Context, reader NonceReader, addr common.Address) (*accountNonce, error) {
+	chain, err := m.chain(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	key := nonceKey{chain: chain, addr: addr}
+	a, ok := m.accounts[key]
+	if !ok {
+		a = &accountNonce{sem: make(chan struct{}, 1)}
+		m.accounts[key] = a
+	}
+	return a, nil
+}
+
+//Acquire returns the next nonce of addr on the chain of reader, other Acquire of addr there wait until release is called;
+//release(true) means the transaction is taken by the node, release(false) means it is not sent
+//and the nonce is read from chain again next time
+func (m *NonceManager) Acquire(ctx context.Context, reader NonceReader, addr common.Address) (uint64, func(sent bool), error) {
+	a, err := m.account(ctx, reader, addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+
+	if !a.known {
+		next, err := reader.PendingNonceAt(ctx, addr)
+		if err != nil {
+			<-a.sem
+			return 0, nil, err
+		}
+		a.next = next
+		a.known = true
+	}
+
+	nonce := a.next
+	released := false
+	release := func(sent bool) {
+		if released {
+			return
+		}
+		released = true
+		if sent {
+			a.next = nonce + 1
+		} else {
+			//未发送成功，与链上对齐
+			a.known = false
+		}
+		<-a.sem
+	}
+	return nonce, release, nil
+}
+
+//Reset forgets the nonce of addr on the chain of reader, the next Acquire reads it from chain
+func (m *NonceManager) Reset(ctx context.Context, reader NonceReader, addr common.Address) error {
+	a, err := m.account(ctx, reader, addr)
+	if err != nil {
+		return err
+	}
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	a.known = false
+	<-a.sem
+	return nil
+}
@@ -0,0 +1,150 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakeNonceReader struct {
+	lk      sync.Mutex
+	pending uint64
+	reads   int
+}
+
+func (r *fakeNonceReader) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.reads++
+	return r.pending, nil
+}
+
+// chainNonceReader is a fakeNonceReader which knows its chain
+type chainNonceReader struct {
+	fakeNonceReader
+	id int64
+}
+
+func (r *chainNonceReader) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(r.id), nil
+}
+
+func TestNonceManager(t *testing.T) {
+	reader := &fakeNonceReader{pending: 5}
+	m := NewNonceManager()
+	addr := common.HexToAddress("0x1")
+
+	var lk sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, release, err := m.Acquire(context.Background(), reader, addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			lk.Lock()
+			if seen[nonce] {
+				t.Errorf("nonce %d is handed out twice", nonce)
+			}
+			seen[nonce] = true
+			lk.Unlock()
+			release(true)
+		}()
+	}
+	wg.Wait()
+
+	for n := uint64(5); n < 25; n++ {
+		if !seen[n] {
+			t.Fatalf("nonce %d is skipped", n)
+		}
+	}
+	if reader.reads != 1 {
+		t.Fatalf("chain is read %d times, want 1", reader.reads)
+	}
+
+	// a transaction not sent makes the manager read the chain again
+	reader.pending = 24
+	nonce, release, err := m.Acquire(context.Background(), reader, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nonce != 25 {
+		t.Fatalf("got nonce %d, want 25", nonce)
+	}
+	release(false)
+
+	nonce, release, err = m.Acquire(context.Background(), reader, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(true)
+	if nonce != 24 {
+		t.Fatalf("got nonce %d after reconciling, want 24", nonce)
+	}
+
+	// a waiting Acquire gives up with its context
+	_, release, err = m.Acquire(context.Background(), reader, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = m.Acquire(ctx, reader, addr)
+	if err != context.Canceled {
+		t.Fatal("acquire does not wait for release:", err)
+	}
+	release(true)
+}
+
+func TestNonceManagerChains(t *testing.T) {
+	m := NewNonceManager()
+	addr := common.HexToAddress("0x1")
+
+	acquire := func(reader NonceReader) uint64 {
+		nonce, release, err := m.Acquire(context.Background(), reader, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release(true)
+		return nonce
+	}
+
+	// one address on two backends without a chain ID
+	a := &fakeNonceReader{pending: 5}
+	b := &fakeNonceReader{pending: 100}
+	if acquire(a) != 5 || acquire(b) != 100 || acquire(a) != 6 || acquire(b) != 101 {
+		t.Fatal("nonces of two backends are mixed")
+	}
+
+	// two backends of one chain share the nonces, another chain does not
+	c1 := &chainNonceReader{fakeNonceReader: fakeNonceReader{pending: 7}, id: 1}
+	c2 := &chainNonceReader{fakeNonceReader: fakeNonceReader{pending: 7}, id: 1}
+	c3 := &chainNonceReader{fakeNonceReader: fakeNonceReader{pending: 7}, id: 3}
+	if acquire(c1) != 7 || acquire(c2) != 8 || acquire(c3) != 7 {
+		t.Fatal("nonces are not kept by chain")
+	}
+
+	// reset forgets only the nonce on its chain
+	if err := m.Reset(context.Background(), c3, addr); err != nil {
+		t.Fatal(err)
+	}
+	if acquire(c3) != 7 || acquire(c1) != 9 {
+		t.Fatal("reset is not kept by chain")
+	}
+
+	// a new client of a known chain leaves nothing behind
+	n := len(m.accounts)
+	for i := 0; i < 10; i++ {
+		acquire(&chainNonceReader{fakeNonceReader: fakeNonceReader{pending: 7}, id: 1})
+	}
+	if len(m.accounts) != n || acquire(c1) != 20 {
+		t.Fatal("nonces of new clients of a chain are kept apart")
+	}
+}
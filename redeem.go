@@ -0,0 +1,403 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/memoio/go-mefs/contracts"
+)
+
+const (
+	defaultRedeemInterval = 10 * time.Minute //how often the channels are checked
+	defaultRedeemRetry    = 3                //attempts for one voucher in one check
+)
+
+//errors of RedeemScheduler
+var (
+	ErrRedeemInterval  = errors.New("redeem interval is not shorter than the margin")
+	ErrRedeemDestroyed = errors.New("channel is destroyed before its vouchers are redeemed")
+)
+
+//Redeemer sends the redemption transactions, met by *contracts.ChannelNodeInfo
+type Redeemer interface {
+	DemandPaymentWithContext(ctx context.Context, channelAddr common.Address, value, nonce *big.Int, sig []byte) error
+	SettlePaymentWithContext(ctx context.Context, channelAddr common.Address, total *big.Int, sig []byte) error
+}
+
+//RedeemReport the outcome of redeeming one voucher
+type RedeemReport struct {
+	Channel common.Address
+	Nonce   *big.Int //nil for the cumulative voucher
+	Value   *big.Int //for the cumulative voucher, its total minus what was withdrawn before
+	Err     error    //nil if the value is claimed, ErrVoucherOverBalance if the balance left cannot pay it
+	Time    time.Time
+}
+
+//redeemBackoff a voucher which failed is not tried again before next
+type redeemBackoff struct {
+	failures int
+	next     time.Time
+}
+
+//RedeemScheduler redeems the vouchers of a VoucherWallet before their channels time out,
+//since the sender takes back all that is left by ChannelTimeout
+type RedeemScheduler struct {
+	wallet   *VoucherWallet
+	chain    ChannelChain
+	redeemer Redeemer
+
+	margin   time.Duration //redeem once the channel expires within margin
+	interval time.Duration //time between two checks in Run, shorter than margin
+
+	Retry int //attempts for one voucher in one check
+
+	lk       sync.Mutex
+	reports  []RedeemReport
+	backoffs map[string]*redeemBackoff   //by redeemKey
+	stopped  map[common.Address]struct{} //channels given up, destroyed or expired
+	now      func() time.Time
+}
+
+//NewRedeemScheduler new a scheduler which redeems the vouchers of a channel once it expires within margin,
+//checking every interval; zero margin is contracts.ExpiringMargin and zero interval is 10 minutes.
+//ErrRedeemInterval is returned if interval is not shorter than margin, a channel could expire between two checks
+func NewRedeemScheduler(wallet *VoucherWallet, chain ChannelChain, redeemer Redeemer, margin, interval time.Duration) (*RedeemScheduler, error) {
+	if margin == 0 {
+		margin = contracts.ExpiringMargin
+	}
+	if interval == 0 {
+		interval = defaultRedeemInterval
+	}
+	if interval >= margin {
+		return nil, ErrRedeemInterval
+	}
+
+	return &RedeemScheduler{
+		wallet:   wallet,
+		chain:    chain,
+		redeemer: redeemer,
+		margin:   margin,
+		interval: interval,
+		Retry:    defaultRedeemRetry,
+		backoffs: make(map[string]*redeemBackoff),
+		stopped:  make(map[common.Address]struct{}),
+		now:      time.Now,
+	}, nil
+}
+
+//Run checks all channels every interval until ctx is done
+func (s *RedeemScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Check redeems the vouchers of every channel expiring within margin, returns what it tried;
+//a voucher which fails waits longer each time before it is tried again.
+//The vouchers of a destroyed channel are reported once with ErrRedeemDestroyed,
+//an expired channel is tried once more and given up if anything fails
+func (s *RedeemScheduler) Check(ctx context.Context) []RedeemReport {
+	channels, err := s.wallet.Channels()
+	if err != nil {
+		log.Println("get channels of wallet fails:", err)
+		return nil
+	}
+
+	var reports []RedeemReport
+	for _, channelAddr := range channels {
+		if ctx.Err() != nil {
+			break
+		}
+		if s.isStopped(channelAddr) {
+			continue
+		}
+
+		state, err := s.chain.GetChannelState(channelAddr)
+		if err != nil {
+			log.Println("get state of channel", channelAddr.String(), "fails:", err)
+			continue
+		}
+
+		switch state.StatusAt(s.now(), s.margin) {
+		case contracts.ChannelOpen:
+			continue
+		case contracts.ChannelDestroyed:
+			reports = append(reports, s.lost(channelAddr)...)
+			s.stop(channelAddr)
+		case contracts.ChannelExpired:
+			//过期后发送者随时可以取回余额，只再试一次
+			rs := s.redeemChannel(ctx, channelAddr, true)
+			for _, r := range rs {
+				if r.Err != nil {
+					log.Println("channel", channelAddr.String(), "has expired, give up its vouchers:", r.Err)
+					s.stop(channelAddr)
+					break
+				}
+			}
+			reports = append(reports, rs...)
+		default:
+			reports = append(reports, s.redeemChannel(ctx, channelAddr, false)...)
+		}
+	}
+
+	s.lk.Lock()
+	s.reports = append(s.reports, reports...)
+	s.lk.Unlock()
+
+	return reports
+}
+
+//redeemChannel redeems all vouchers of the channel its balance can pay, and settles its latest cumulative voucher;
+//the vouchers waiting for their backoff are skipped unless last
+func (s *RedeemScheduler) redeemChannel(ctx context.Context, channelAddr common.Address, last bool) []RedeemReport {
+	vs, short, err := s.wallet.Redeemable(channelAddr)
+	if err != nil {
+		log.Println("get redeemable vouchers of channel", channelAddr.String(), "fails:", err)
+		return nil
+	}
+
+	reports := make([]RedeemReport, 0, len(vs)+len(short)+1)
+	for _, v := range vs {
+		key := redeemKey(channelAddr, v.Nonce)
+		if !last && !s.due(key) {
+			continue
+		}
+		report := RedeemReport{
+			Channel: channelAddr,
+			Nonce:   v.Nonce,
+			Value:   v.Value,
+		}
+		report.Err = s.redeem(ctx, v)
+		report.Time = s.now()
+		reports = append(reports, report)
+	}
+	//余额不足的凭证不发送交易，但要报告
+	for _, v := range short {
+		key := redeemKey(channelAddr, v.Nonce)
+		if !last && !s.due(key) {
+			continue
+		}
+		s.fail(key)
+		reports = append(reports, RedeemReport{
+			Channel: channelAddr,
+			Nonce:   v.Nonce,
+			Value:   v.Value,
+			Err:     ErrVoucherOverBalance,
+			Time:    s.now(),
+		})
+	}
+
+	//累计凭证只需结算最新的一张
+	latest, err := s.wallet.LatestTotal(channelAddr)
+	if err == ErrNoVoucher || (err == nil && latest.Redeemed) {
+		return reports
+	}
+	if err != nil {
+		log.Println("get cumulative voucher of channel", channelAddr.String(), "fails:", err)
+		return reports
+	}
+	if !last && !s.due(redeemKey(channelAddr, nil)) {
+		return reports
+	}
+	paid, err := s.chain.GetWithdrawn(channelAddr, latest.Recipient)
+	if err != nil {
+		log.Println("get withdrawn of channel", channelAddr.String(), "fails:", err)
+		return reports
+	}
+	report := RedeemReport{
+		Channel: channelAddr,
+		Value:   new(big.Int).Sub(latest.Value, paid),
+	}
+	report.Err = s.settle(ctx, latest)
+	report.Time = s.now()
+	return append(reports, report)
+}
+
+//lost reports every voucher of the destroyed channel which is not redeemed
+func (s *RedeemScheduler) lost(channelAddr common.Address) []RedeemReport {
+	vs, err := s.wallet.Vouchers(channelAddr)
+	if err != nil {
+		log.Println("get vouchers of channel", channelAddr.String(), "fails:", err)
+		return nil
+	}
+	latest, err := s.wallet.LatestTotal(channelAddr)
+	if err == nil && !latest.Redeemed {
+		vs = append(vs, latest)
+	} else if err != nil && err != ErrNoVoucher {
+		log.Println("get cumulative voucher of channel", channelAddr.String(), "fails:", err)
+	}
+
+	reports := make([]RedeemReport, 0, len(vs))
+	for _, v := range vs {
+		reports = append(reports, RedeemReport{
+			Channel: channelAddr,
+			Nonce:   v.Nonce,
+			Value:   v.Value,
+			Err:     ErrRedeemDestroyed,
+			Time:    s.now(),
+		})
+	}
+	return reports
+}
+
+func (s *RedeemScheduler) redeem(ctx context.Context, v *Voucher) error {
+	key := redeemKey(v.Channel, v.Nonce)
+	sig, err := v.Sig()
+	if err != nil {
+		s.fail(key)
+		return err
+	}
+
+	for i := 0; i < s.Retry; i++ {
+		err = s.redeemer.DemandPaymentWithContext(ctx, v.Channel, v.Value, v.Nonce, sig)
+		if err == nil || errors.Is(err, contracts.ErrNonceUsed) {
+			//a used nonce has been paid already, nothing more to claim
+			errMark := s.wallet.MarkRedeemed(v.Channel, v.Nonce)
+			if errMark != nil {
+				log.Println("mark voucher redeemed fails:", errMark)
+			}
+			s.clear(key)
+			return err
+		}
+		log.Println("redeem voucher", v.Nonce, "of channel", v.Channel.String(), "fails:", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	s.fail(key)
+	return err
+}
+
+func (s *RedeemScheduler) settle(ctx context.Context, v *Voucher) error {
+	key := redeemKey(v.Channel, nil)
+	sig, err := v.Sig()
+	if err != nil {
+		s.fail(key)
+		return err
+	}
+
+	for i := 0; i < s.Retry; i++ {
+		err = s.redeemer.SettlePaymentWithContext(ctx, v.Channel, v.Value, sig)
+		if err == nil || errors.Is(err, contracts.ErrTotalWithdrawn) || errors.Is(err, contracts.ErrIllegalTotal) {
+			//the total has been withdrawn already, nothing more to claim
+			errMark := s.wallet.MarkTotalSettled(v.Channel, v.Value)
+			if errMark != nil {
+				log.Println("mark cumulative voucher settled fails:", errMark)
+			}
+			s.clear(key)
+			return err
+		}
+		log.Println("settle cumulative voucher of channel", v.Channel.String(), "fails:", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	s.fail(key)
+	return err
+}
+
+//redeemKey the key of a voucher in backoffs, nonce is nil for the cumulative voucher
+func redeemKey(channelAddr common.Address, nonce *big.Int) string {
+	if nonce == nil {
+		return channelAddr.Hex() + "/total"
+	}
+	return channelAddr.Hex() + "/" + nonce.String()
+}
+
+//due reports whether the voucher of key is not waiting for its backoff
+func (s *RedeemScheduler) due(key string) bool {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	b, ok := s.backoffs[key]
+	return !ok || !s.now().Before(b.next)
+}
+
+//fail makes the voucher of key wait before it is tried again,
+//interval after the first failure and twice as long after each one more, but at most half of margin
+func (s *RedeemScheduler) fail(key string) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	b, ok := s.backoffs[key]
+	if !ok {
+		b = new(redeemBackoff)
+		s.backoffs[key] = b
+	}
+	b.failures++
+
+	wait := s.interval
+	for i := 1; i < b.failures && wait < s.margin/2; i++ {
+		wait *= 2
+	}
+	if wait > s.margin/2 {
+		wait = s.margin / 2
+	}
+	b.next = s.now().Add(wait)
+}
+
+//clear forgets the failures of the voucher of key
+func (s *RedeemScheduler) clear(key string) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	delete(s.backoffs, key)
+}
+
+//stop gives the channel up, it is not checked any more
+func (s *RedeemScheduler) stop(channelAddr common.Address) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.stopped[channelAddr] = struct{}{}
+	prefix := channelAddr.Hex() + "/"
+	for key := range s.backoffs {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.backoffs, key)
+		}
+	}
+}
+
+func (s *RedeemScheduler) isStopped(channelAddr common.Address) bool {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	_, ok := s.stopped[channelAddr]
+	return ok
+}
+
+//Reports returns the outcome of every redemption tried so far
+func (s *RedeemScheduler) Reports() []RedeemReport {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return append([]RedeemReport(nil), s.reports...)
+}
+
+//Claimed returns the total value claimed so far
+func (s *RedeemScheduler) Claimed() *big.Int {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	sum := new(big.Int)
+	for _, r := range s.reports {
+		if r.Err == nil {
+			sum.Add(sum, r.Value)
+		}
+	}
+	return sum
+}
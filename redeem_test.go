@@ -0,0 +1,322 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+type fakeRedeemer struct {
+	fails   int //calls failing before one succeeds
+	calls   int
+	paid    []*big.Int
+	settled []*big.Int
+}
+
+func (r *fakeRedeemer) DemandPaymentWithContext(ctx context.Context, channelAddr common.Address, value, nonce *big.Int, sig []byte) error {
+	r.calls++
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("tx fails")
+	}
+	for _, n := range r.paid {
+		if n.Cmp(nonce) == 0 {
+			return contracts.ErrNonceUsed
+		}
+	}
+	r.paid = append(r.paid, nonce)
+	return nil
+}
+
+func (r *fakeRedeemer) SettlePaymentWithContext(ctx context.Context, channelAddr common.Address, total *big.Int, sig []byte) error {
+	r.calls++
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("tx fails")
+	}
+	for _, s := range r.settled {
+		if s.Cmp(total) >= 0 {
+			return contracts.ErrIllegalTotal
+		}
+	}
+	r.settled = append(r.settled, total)
+	return nil
+}
+
+func TestRedeemScheduler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redeem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payerSk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := contracts.NewECDSASigner(payerSk)
+
+	provider := common.HexToAddress("0x2")
+	channelAddr := common.HexToAddress("0x1")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{
+		sender:     payer.Address(),
+		recipients: []common.Address{provider},
+		balance:    big.NewInt(100),
+	}
+	w, err := OpenVoucherWallet(dir, provider, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for i, value := range []int64{30, 60} {
+		mes, err := SignForChannelPayWithSigner(channelID, payer, provider, big.NewInt(value), big.NewInt(int64(i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Receive(mes)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	redeemer := &fakeRedeemer{fails: 1}
+	s, err := NewRedeemScheduler(w, chain, redeemer, 10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//fakeChain channels expire at 3600
+	s.now = func() time.Time { return time.Unix(0, 0) }
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatalf("redeemed %d vouchers before the margin", len(reports))
+	}
+
+	s.now = func() time.Time { return time.Unix(3600-300, 0) }
+	reports := s.Check(context.Background())
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports, want 2", len(reports))
+	}
+	for _, r := range reports {
+		if r.Err != nil {
+			t.Fatalf("redeem voucher %d fails: %s", r.Nonce, r.Err)
+		}
+	}
+	if s.Claimed().Cmp(big.NewInt(90)) != 0 {
+		t.Fatalf("claimed %d, want 90", s.Claimed())
+	}
+
+	channels, err := w.Channels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 0 {
+		t.Fatalf("%d channels still have vouchers after redemption", len(channels))
+	}
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatalf("redeemed %d vouchers twice", len(reports))
+	}
+}
+
+func TestRedeemSchedulerTotal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redeem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payerSk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := contracts.NewECDSASigner(payerSk)
+
+	provider := common.HexToAddress("0x2")
+	channelAddr := common.HexToAddress("0x1")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{
+		sender:     payer.Address(),
+		recipients: []common.Address{provider},
+		balance:    big.NewInt(100),
+		withdrawn:  map[common.Address]*big.Int{provider: big.NewInt(10)},
+	}
+	w, err := OpenVoucherWallet(dir, provider, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	receive := func(total int64) {
+		mes, err := SignForChannelTotalWithSigner(channelID, payer, provider, big.NewInt(total))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.ReceiveTotal(mes)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive(30)
+	receive(50)
+
+	redeemer := &fakeRedeemer{fails: 1}
+	s, err := NewRedeemScheduler(w, chain, redeemer, 10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return time.Unix(3600-300, 0) }
+
+	// only the latest total is settled, and it claims what is not withdrawn yet
+	reports := s.Check(context.Background())
+	if len(reports) != 1 || reports[0].Err != nil || reports[0].Nonce != nil {
+		t.Fatal("cumulative voucher is not settled:", reports)
+	}
+	if len(redeemer.settled) != 1 || redeemer.settled[0].Int64() != 50 || s.Claimed().Int64() != 40 {
+		t.Fatalf("settled %v, claimed %d", redeemer.settled, s.Claimed())
+	}
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatalf("settled %d vouchers twice", len(reports))
+	}
+
+	// a larger total received later is settled again
+	chain.withdrawn[provider] = big.NewInt(50)
+	receive(70)
+	reports = s.Check(context.Background())
+	if len(reports) != 1 || reports[0].Err != nil || reports[0].Value.Int64() != 20 {
+		t.Fatal("new cumulative voucher is not settled:", reports)
+	}
+
+	// a total settled elsewhere is marked and not tried again
+	receive(80)
+	redeemer.settled = append(redeemer.settled, big.NewInt(80))
+	reports = s.Check(context.Background())
+	if len(reports) != 1 || reports[0].Err != contracts.ErrIllegalTotal {
+		t.Fatal("settled total is not reported:", reports)
+	}
+	channels, err := w.Channels()
+	if err != nil || len(channels) != 0 {
+		t.Fatal("channel still has a cumulative voucher to settle:", channels, err)
+	}
+}
+
+func TestRedeemSchedulerGiveUp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redeem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payerSk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := contracts.NewECDSASigner(payerSk)
+
+	provider := common.HexToAddress("0x2")
+	channelAddr := common.HexToAddress("0x1")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{
+		sender:     payer.Address(),
+		recipients: []common.Address{provider},
+		balance:    big.NewInt(100),
+	}
+	w, err := OpenVoucherWallet(dir, provider, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for i, value := range []int64{30, 60} {
+		mes, err := SignForChannelPayWithSigner(channelID, payer, provider, big.NewInt(value), big.NewInt(int64(i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Receive(mes)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	redeemer := &fakeRedeemer{fails: 1000}
+	_, err = NewRedeemScheduler(w, chain, redeemer, 10*time.Minute, 10*time.Minute)
+	if err != ErrRedeemInterval {
+		t.Fatalf("got %v, want %v", err, ErrRedeemInterval)
+	}
+	s, err := NewRedeemScheduler(w, chain, redeemer, 10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Retry = 1
+
+	// the balance only pays the voucher of 60, the one of 30 is reported without a transaction
+	chain.balance = big.NewInt(80)
+	now := time.Unix(3600-300, 0)
+	s.now = func() time.Time { return now }
+	reports := s.Check(context.Background())
+	if len(reports) != 2 || redeemer.calls != 1 || reports[0].Err == nil || reports[1].Err != ErrVoucherOverBalance {
+		t.Fatalf("got %v after %d calls", reports, redeemer.calls)
+	}
+
+	// failed vouchers wait an interval, then twice as long
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatalf("tried %d vouchers again without backoff", len(reports))
+	}
+	now = now.Add(time.Minute)
+	if reports := s.Check(context.Background()); len(reports) != 2 || redeemer.calls != 2 {
+		t.Fatalf("got %d reports after %d calls, want 2 after 2", len(reports), redeemer.calls)
+	}
+	now = now.Add(time.Minute)
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatalf("tried %d vouchers again before the backoff doubles", len(reports))
+	}
+	now = now.Add(time.Minute)
+	if reports := s.Check(context.Background()); len(reports) != 2 {
+		t.Fatalf("got %d reports, want 2", len(reports))
+	}
+
+	// an expired channel is tried once more and given up
+	now = time.Unix(3600, 0)
+	if reports := s.Check(context.Background()); len(reports) != 2 || redeemer.calls != 4 {
+		t.Fatalf("got %d reports after %d calls, want 2 after 4", len(reports), redeemer.calls)
+	}
+	now = now.Add(time.Hour)
+	if reports := s.Check(context.Background()); len(reports) != 0 || redeemer.calls != 4 {
+		t.Fatal("expired channel is tried again:", reports)
+	}
+
+	// the vouchers of a destroyed channel are reported once and never sent
+	s, err = NewRedeemScheduler(w, chain, redeemer, 10*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.destroyed = true
+	reports = s.Check(context.Background())
+	if len(reports) != 2 || reports[0].Err != ErrRedeemDestroyed || reports[1].Err != ErrRedeemDestroyed || redeemer.calls != 4 {
+		t.Fatalf("got %v after %d calls", reports, redeemer.calls)
+	}
+	if reports := s.Check(context.Background()); len(reports) != 0 {
+		t.Fatal("destroyed channel is reported again:", reports)
+	}
+}
@@ -0,0 +1,91 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+//errors of the require messages in Channel.sol
+var (
+	ErrIllegalCaller = errors.New("caller is not a recipient of the channel")
+	ErrIllegalHash   = errors.New("hash does not match value, nonce and recipient")
+	ErrIllegalSig    = errors.New("voucher is not signed by the channel sender")
+	ErrIllegalTotal  = errors.New("voucher total is not above what has been withdrawn")
+	ErrIllegalSender = errors.New("only the channel factory deploys a channel for another sender")
+	ErrTimeNotUp     = errors.New("channel has not timed out")
+	ErrDeployBanned  = errors.New("deploying channel is banned")
+	ErrExtendBanned  = errors.New("extending channel is banned")
+	//ErrReverted the call reverts without one of the reasons above
+	ErrReverted = errors.New("execution reverted")
+)
+
+//revertReasons maps the require messages of Channel.sol to their errors
+var revertReasons = map[string]error{
+	"illegal caller":           ErrIllegalCaller,
+	"illegal nonce":            ErrNonceUsed,
+	"illegal hash":             ErrIllegalHash,
+	"illegal sig":              ErrIllegalSig,
+	"illegal total":            ErrIllegalTotal,
+	"illegal sender":           ErrIllegalSender,
+	"Time is not up":           ErrTimeNotUp,
+	"deploy channel is banned": ErrDeployBanned,
+	"extend is banned":         ErrExtendBanned,
+}
+
+//revertReason gets the revert reason out of an error returned by the node,
+//ok is false if err is not a revert
+func revertReason(err error) (reason string, ok bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, isStr := dataErr.ErrorData().(string); isStr {
+			reason, errUnpack := abi.UnpackRevert(common.FromHex(data))
+			if errUnpack == nil {
+				return reason, true
+			}
+		}
+	}
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "execution reverted") {
+		return "", false
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(msg, "execution reverted"), ": "), true
+}
+
+//decodeRevert returns the error of the revert carried by err, nil if err is not a revert
+func decodeRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	reason, ok := revertReason(err)
+	if !ok {
+		return nil
+	}
+	if e, ok := revertReasons[reason]; ok {
+		return e
+	}
+	return ErrReverted
+}
+
+//simulateTx replays tx from 'from' as a call on the latest state,
+//returns the error of its revert or nil if it does not revert there
+func simulateTx(ctx context.Context, caller bind.ContractCaller, from common.Address, tx *types.Transaction) error {
+	_, err := caller.CallContract(ctx, ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}, nil)
+	return decodeRevert(err)
+}
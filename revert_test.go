@@ -0,0 +1,34 @@
+package contracts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{nil, nil},
+		{errors.New("insufficient funds for gas * price + value"), nil},
+		{errors.New("execution reverted"), ErrReverted},
+		{errors.New("execution reverted: illegal caller"), ErrIllegalCaller},
+		{errors.New("execution reverted: illegal nonce"), ErrNonceUsed},
+		{errors.New("execution reverted: illegal hash"), ErrIllegalHash},
+		{errors.New("execution reverted: illegal sig"), ErrIllegalSig},
+		{errors.New("execution reverted: illegal total"), ErrIllegalTotal},
+		{errors.New("execution reverted: illegal sender"), ErrIllegalSender},
+		{errors.New("execution reverted: Time is not up"), ErrTimeNotUp},
+		{errors.New("execution reverted: deploy channel is banned"), ErrDeployBanned},
+		{errors.New("execution reverted: extend is banned"), ErrExtendBanned},
+		{errors.New("execution reverted: only owner can call"), ErrReverted},
+	}
+
+	for _, test := range tests {
+		got := decodeRevert(test.err)
+		if got != test.want {
+			t.Errorf("decodeRevert(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -19,6 +19,17 @@ import (
 
 //SignForChannel user sends a private key signature to the provider
 func SignForChannel(channelID, hexKey string, value *big.Int) (sig []byte, err error) {
+	//私钥格式转换
+	skECDSA, err := id.ECDSAStringToSk(hexKey)
+	if err != nil {
+		return sig, err
+	}
+
+	return SignForChannelWithSigner(channelID, contracts.NewECDSASigner(skECDSA), value)
+}
+
+//SignForChannelWithSigner is SignForChannel which signs with signer
+func SignForChannelWithSigner(channelID string, signer contracts.Signer, value *big.Int) (sig []byte, err error) {
 	channelAddr, err := address.GetAddressFromID(channelID)
 	if err != nil {
 		return nil, err
@@ -28,30 +39,81 @@ func SignForChannel(channelID, hexKey string, value *big.Int) (sig []byte, err e
 	valueNew := common.LeftPadBytes(value.Bytes(), 32)
 	hash := crypto.Keccak256(channelAddr.Bytes(), valueNew) //32Byte
 
-	//私钥格式转换
+	message, err := signChannel(channelID, signer, hash, value)
+	if err != nil {
+		return nil, err
+	}
+
+	mes, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return mes, nil
+}
+
+//SignForChannelPay user signs a voucher for one recipient of the channel-contract,
+//the hash matches the one recomputed by DemandPayment in the contract
+func SignForChannelPay(channelID, hexKey string, recipient common.Address, value, nonce *big.Int) (sig []byte, err error) {
 	skECDSA, err := id.ECDSAStringToSk(hexKey)
 	if err != nil {
 		return sig, err
 	}
 
-	//私钥对上述哈希值签名
-	sig, err = crypto.Sign(hash, skECDSA)
+	return SignForChannelPayWithSigner(channelID, contracts.NewECDSASigner(skECDSA), recipient, value, nonce)
+}
+
+//SignForChannelPayWithSigner is SignForChannelPay which signs with signer
+func SignForChannelPayWithSigner(channelID string, signer contracts.Signer, recipient common.Address, value, nonce *big.Int) (sig []byte, err error) {
+	channelAddr, err := address.GetAddressFromID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	//keccak256(abi.encodePacked(channelAddress, value, nonce, recipient))
+	hash := channelPayHash(channelAddr, recipient, value.Bytes(), nonce.Bytes())
+
+	message, err := signChannel(channelID, signer, hash, value)
+	if err != nil {
+		return nil, err
+	}
+	message.Nonce = nonce.Bytes()
+	message.Recipient = recipient.Bytes()
+
+	mes, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return mes, nil
+}
+
+//SignForChannelTotal user signs a cumulative voucher for one recipient of the channel-contract,
+//total is everything owed to the recipient so far and SettlePayment pays what is not withdrawn yet
+func SignForChannelTotal(channelID, hexKey string, recipient common.Address, total *big.Int) (sig []byte, err error) {
+	skECDSA, err := id.ECDSAStringToSk(hexKey)
 	if err != nil {
 		return sig, err
 	}
 
-	pubKey, err := id.GetCompressPubByte(hexKey)
+	return SignForChannelTotalWithSigner(channelID, contracts.NewECDSASigner(skECDSA), recipient, total)
+}
+
+//SignForChannelTotalWithSigner is SignForChannelTotal which signs with signer
+func SignForChannelTotalWithSigner(channelID string, signer contracts.Signer, recipient common.Address, total *big.Int) (sig []byte, err error) {
+	channelAddr, err := address.GetAddressFromID(channelID)
 	if err != nil {
-		utils.MLogger.Error("Get public key fail: ", err)
 		return nil, err
 	}
 
-	message := &mpb.ChannelSign{
-		Sig:       sig,
-		PubKey:    pubKey,
-		Value:     value.Bytes(),
-		ChannelID: channelID,
+	//keccak256(abi.encodePacked(channelAddress, total, recipient))
+	hash := channelTotalHash(channelAddr, recipient, total.Bytes())
+
+	message, err := signChannel(channelID, signer, hash, total)
+	if err != nil {
+		return nil, err
 	}
+	message.Recipient = recipient.Bytes()
 
 	mes, err := proto.Marshal(message)
 	if err != nil {
@@ -61,17 +123,134 @@ func SignForChannel(channelID, hexKey string, value *big.Int) (sig []byte, err e
 	return mes, nil
 }
 
+//SignForTokenChannelPay user signs a voucher for one recipient of a token channel-contract;
+//token is in the hash, so the voucher cannot be redeemed from a channel of another token
+func SignForTokenChannelPay(channelID, hexKey string, token, recipient common.Address, value, nonce *big.Int) (sig []byte, err error) {
+	skECDSA, err := id.ECDSAStringToSk(hexKey)
+	if err != nil {
+		return sig, err
+	}
+
+	return SignForTokenChannelPayWithSigner(channelID, contracts.NewECDSASigner(skECDSA), token, recipient, value, nonce)
+}
+
+//SignForTokenChannelPayWithSigner is SignForTokenChannelPay which signs with signer
+func SignForTokenChannelPayWithSigner(channelID string, signer contracts.Signer, token, recipient common.Address, value, nonce *big.Int) (sig []byte, err error) {
+	channelAddr, err := address.GetAddressFromID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	//keccak256(abi.encodePacked(channelAddress, token, value, nonce, recipient))
+	hash := tokenChannelPayHash(channelAddr, token, recipient, value.Bytes(), nonce.Bytes())
+
+	message, err := signChannel(channelID, signer, hash, value)
+	if err != nil {
+		return nil, err
+	}
+	message.Nonce = nonce.Bytes()
+	message.Recipient = recipient.Bytes()
+
+	mes, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return mes, nil
+}
+
+//signChannel signs hash with signer and fills the common fields of ChannelSign
+func signChannel(channelID string, signer contracts.Signer, hash []byte, value *big.Int) (*mpb.ChannelSign, error) {
+	//私钥对上述哈希值签名
+	sig, err := signer.SignHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		utils.MLogger.Error("Get public key fail: ", err)
+		return nil, err
+	}
+
+	return &mpb.ChannelSign{
+		Sig:       sig,
+		PubKey:    crypto.CompressPubkey(pub),
+		Value:     value.Bytes(),
+		ChannelID: channelID,
+	}, nil
+}
+
 //VerifyChannelSign provider used to verify user's signature for channel-contract
 func VerifyChannelSign(cSign *mpb.ChannelSign) (verify bool) {
 	channelAddr, err := address.GetAddressFromID(cSign.GetChannelID())
 	if err != nil {
 		return false
 	}
+	if len(cSign.GetSig()) < 64 {
+		return false
+	}
 
-	//(channelAddress, value)的哈希值
-	valueNew := common.LeftPadBytes(cSign.GetValue(), 32)
-	hash := crypto.Keccak256(channelAddr.Bytes(), valueNew)
+	var hash []byte
+	if len(cSign.GetRecipient()) != 0 {
+		//(channelAddress, value, nonce, recipient)的哈希值
+		hash = channelPayHash(channelAddr, common.BytesToAddress(cSign.GetRecipient()), cSign.GetValue(), cSign.GetNonce())
+	} else {
+		//(channelAddress, value)的哈希值
+		valueNew := common.LeftPadBytes(cSign.GetValue(), 32)
+		hash = crypto.Keccak256(channelAddr.Bytes(), valueNew)
+	}
 
 	//验证签名
 	return crypto.VerifySignature(cSign.GetPubKey(), hash, cSign.GetSig()[:64])
 }
+
+//VerifyChannelTotalSign provider used to verify user's signature of a cumulative voucher,
+//whose Value is the total owed so far
+func VerifyChannelTotalSign(cSign *mpb.ChannelSign) (verify bool) {
+	channelAddr, err := address.GetAddressFromID(cSign.GetChannelID())
+	if err != nil {
+		return false
+	}
+	if len(cSign.GetRecipient()) == 0 || len(cSign.GetSig()) < 64 {
+		return false
+	}
+
+	hash := channelTotalHash(channelAddr, common.BytesToAddress(cSign.GetRecipient()), cSign.GetValue())
+	return crypto.VerifySignature(cSign.GetPubKey(), hash, cSign.GetSig()[:64])
+}
+
+//VerifyTokenChannelSign provider used to verify user's signature for a token channel-contract;
+//the message does not carry the token, so it is the one the channel pays in, see GetChannelToken
+func VerifyTokenChannelSign(cSign *mpb.ChannelSign, token common.Address) (verify bool) {
+	channelAddr, err := address.GetAddressFromID(cSign.GetChannelID())
+	if err != nil {
+		return false
+	}
+	if len(cSign.GetRecipient()) == 0 || len(cSign.GetSig()) < 64 {
+		return false
+	}
+
+	hash := tokenChannelPayHash(channelAddr, token, common.BytesToAddress(cSign.GetRecipient()), cSign.GetValue(), cSign.GetNonce())
+	return crypto.VerifySignature(cSign.GetPubKey(), hash, cSign.GetSig()[:64])
+}
+
+//channelPayHash returns keccak256(abi.encodePacked(channel, value, nonce, recipient))
+func channelPayHash(channelAddr, recipient common.Address, value, nonce []byte) []byte {
+	valueNew := common.LeftPadBytes(value, 32)
+	nonceNew := common.LeftPadBytes(nonce, 32)
+	return crypto.Keccak256(channelAddr.Bytes(), valueNew, nonceNew, recipient.Bytes())
+}
+
+//channelTotalHash returns keccak256(abi.encodePacked(channel, total, recipient))
+func channelTotalHash(channelAddr, recipient common.Address, total []byte) []byte {
+	totalNew := common.LeftPadBytes(total, 32)
+	return crypto.Keccak256(channelAddr.Bytes(), totalNew, recipient.Bytes())
+}
+
+//tokenChannelPayHash returns keccak256(abi.encodePacked(channel, token, value, nonce, recipient))
+func tokenChannelPayHash(channelAddr, token, recipient common.Address, value, nonce []byte) []byte {
+	valueNew := common.LeftPadBytes(value, 32)
+	nonceNew := common.LeftPadBytes(nonce, 32)
+	return crypto.Keccak256(channelAddr.Bytes(), token.Bytes(), valueNew, nonceNew, recipient.Bytes())
+}
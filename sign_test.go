@@ -0,0 +1,97 @@
+package role
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gogo/protobuf/proto"
+	"github.com/memoio/go-mefs/contracts"
+	mpb "github.com/memoio/go-mefs/pb"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+func TestSignForTokenChannelPay(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := contracts.NewECDSASigner(sk)
+
+	channelAddr := common.HexToAddress("0x1")
+	recipient := common.HexToAddress("0x2")
+	token := common.HexToAddress("0x3")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mes, err := SignForTokenChannelPayWithSigner(channelID, signer, token, recipient, big.NewInt(10), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cSign := new(mpb.ChannelSign)
+	err = proto.Unmarshal(mes, cSign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifyTokenChannelSign(cSign, token) {
+		t.Fatal("token voucher does not verify")
+	}
+	if VerifyTokenChannelSign(cSign, common.HexToAddress("0x4")) {
+		t.Fatal("token voucher verifies for another token")
+	}
+	if VerifyChannelSign(cSign) {
+		t.Fatal("token voucher verifies as a coin voucher")
+	}
+}
+
+func TestVerifyShortSig(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := contracts.NewECDSASigner(sk)
+
+	channelID, err := address.GetIDFromAddress(common.HexToAddress("0x1").String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := common.HexToAddress("0x2")
+	token := common.HexToAddress("0x3")
+
+	pay, err := SignForChannelPayWithSigner(channelID, signer, recipient, big.NewInt(10), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := SignForChannelTotalWithSigner(channelID, signer, recipient, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenPay, err := SignForTokenChannelPayWithSigner(channelID, signer, token, recipient, big.NewInt(10), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := map[string]func(*mpb.ChannelSign) bool{
+		"pay":   VerifyChannelSign,
+		"total": VerifyChannelTotalSign,
+		"token": func(cSign *mpb.ChannelSign) bool { return VerifyTokenChannelSign(cSign, token) },
+	}
+	mes := map[string][]byte{"pay": pay, "total": total, "token": tokenPay}
+	for name, v := range verify {
+		for _, sig := range [][]byte{nil, make([]byte, 10), make([]byte, 63)} {
+			cSign := new(mpb.ChannelSign)
+			err = proto.Unmarshal(mes[name], cSign)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cSign.Sig = sig
+			if v(cSign) {
+				t.Fatalf("%s voucher verifies with a %d-byte signature", name, len(sig))
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package contracts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//ErrNoSigner no signer is set for sending transactions
+var ErrNoSigner = errors.New("no signer")
+
+//ErrWrongSigner the transaction is not from the signer's account
+var ErrWrongSigner = errors.New("not authorized to sign this account")
+
+//Signer holds a private key and signs with it without handing it out
+type Signer interface {
+	//Address returns the account of the key
+	Address() common.Address
+	//SignHash returns the 65-byte [R || S || V] signature of hash
+	SignHash(hash []byte) ([]byte, error)
+	//SignTx signs tx with signer
+	SignTx(signer types.Signer, tx *types.Transaction) (*types.Transaction, error)
+}
+
+//keySigner Signer of an in-memory private key
+type keySigner struct {
+	addr common.Address
+	sk   *ecdsa.PrivateKey
+}
+
+//NewKeySigner new a Signer from an in-memory hex private key
+func NewKeySigner(hexSk string) (Signer, error) {
+	sk, err := crypto.HexToECDSA(strings.TrimPrefix(hexSk, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewECDSASigner(sk), nil
+}
+
+//NewECDSASigner new a Signer from an in-memory private key
+func NewECDSASigner(sk *ecdsa.PrivateKey) Signer {
+	return &keySigner{
+		addr: crypto.PubkeyToAddress(sk.PublicKey),
+		sk:   sk,
+	}
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *keySigner) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.sk)
+}
+
+func (s *keySigner) SignTx(signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, signer, s.sk)
+}
+
+//keystoreSigner Signer of an account unlocked in a go-ethereum keystore
+type keystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+//NewKeystoreSigner new a Signer from an encrypted go-ethereum keystore file,
+//the decrypted key stays inside the keystore
+func NewKeystoreSigner(keyFile, passphrase string) (Signer, error) {
+	keyFile, err := filepath.Abs(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := keystore.NewKeyStore(filepath.Dir(keyFile), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.Find(accounts.Account{
+		URL: accounts.URL{Scheme: keystore.KeyStoreScheme, Path: keyFile},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = ks.Unlock(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keystoreSigner{
+		ks:      ks,
+		account: account,
+	}, nil
+}
+
+func (s *keystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *keystoreSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.ks.SignHash(s.account, hash)
+}
+
+func (s *keystoreSigner) SignTx(signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	h := signer.Hash(tx)
+	sig, err := s.ks.SignHash(s.account, h[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+//chainID returns policy.ChainID or else the chain ID read from backend, nil if neither knows it
+func chainID(ctx context.Context, backend interface{}, policy *TxPolicy) (*big.Int, error) {
+	if policy != nil && policy.ChainID != nil {
+		return policy.ChainID, nil
+	}
+	if cr, ok := backend.(chainIDReader); ok {
+		return cr.ChainID(ctx)
+	}
+	return nil, nil
+}
+
+//chainSigner returns the EIP-155 signer of the chain of backend, see chainID;
+//transactions are signed unprotected only if the chain ID is unknown
+func chainSigner(ctx context.Context, backend interface{}, policy *TxPolicy) (types.Signer, error) {
+	id, err := chainID(ctx, backend, policy)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return types.HomesteadSigner{}, nil
+	}
+	return types.NewEIP155Signer(id), nil
+}
+
+//makeSignerAuth is makeAuth which signs with signer for the chain of txSigner
+func makeSignerAuth(signer Signer, txSigner types.Signer, moneyToContract, nonce, gasPrice *big.Int, gasLimit uint64) (*bind.TransactOpts, error) {
+	if signer == nil {
+		return nil, ErrNoSigner
+	}
+
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From:  from,
+		Nonce: nonce,
+		//bind给出的是HomesteadSigner，按链签名
+		Signer: func(_ types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != from {
+				return nil, ErrWrongSigner
+			}
+			return signer.SignTx(txSigner, tx)
+		},
+		Value:    moneyToContract,
+		GasPrice: gasPrice,
+		GasLimit: gasLimit,
+	}, nil
+}
@@ -0,0 +1,327 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+//ChannelVersion the version constant of Channel.sol and TokenChannel.sol the bindings are generated from;
+//version 1 has neither GetVersion nor the closeChannel event
+const ChannelVersion uint16 = 2
+
+//ErrChannelNotDeployed there is no code at the address, and neither code nor a closeChannel event
+//at or after the block the channel-contract is said to be deployed at
+var ErrChannelNotDeployed = errors.New("channel-contract is not deployed")
+
+//ExpiringMargin a channel is ChannelExpiring once it times out within the margin
+var ExpiringMargin = time.Hour
+
+//ChannelStatus lifecycle status of a channel-contract
+type ChannelStatus uint8
+
+//status of ChannelState
+const (
+	ChannelOpen      ChannelStatus = iota //vouchers can be redeemed
+	ChannelExpiring                       //times out within ExpiringMargin, redeem now
+	ChannelExpired                        //timed out, the sender can take the balance back by ChannelTimeout
+	ChannelDestroyed                      //ChannelTimeout has been called, the code is gone
+)
+
+func (s ChannelStatus) String() string {
+	switch s {
+	case ChannelOpen:
+		return "open"
+	case ChannelExpiring:
+		return "expiring"
+	case ChannelExpired:
+		return "expired"
+	case ChannelDestroyed:
+		return "destroyed"
+	default:
+		return "unknown"
+	}
+}
+
+//ChannelState everything about a channel-contract read from chain at one block;
+//only Address, Block, ChainTime and Status are set for a destroyed channel
+type ChannelState struct {
+	Address    common.Address
+	Sender     common.Address
+	Recipients []common.Address
+	Owner      common.Address
+	Start      time.Time
+	Timeout    time.Duration
+	Expiry     time.Time      //Start+Timeout, ChannelTimeout succeeds from then on
+	Token      common.Address //token a TokenChannel pays in, zero for a channel paying native coin
+	Balance    *big.Int       //in Token if it is set
+	Version    uint16         //read from the contract, 1 for the channels without GetVersion
+	Status     ChannelStatus
+	Block      uint64    //latest block when the state is read, all fields are read at it
+	ChainTime  time.Time //time of that block, Status is computed with it
+}
+
+//StatusAt returns the status of the channel at time now, expiring within margin
+func (s *ChannelState) StatusAt(now time.Time, margin time.Duration) ChannelStatus {
+	if s.Status == ChannelDestroyed {
+		return ChannelDestroyed
+	}
+	if !now.Before(s.Expiry) {
+		return ChannelExpired
+	}
+	if !now.Add(margin).Before(s.Expiry) {
+		return ChannelExpiring
+	}
+	return ChannelOpen
+}
+
+//IsRecipient reports whether addr can redeem vouchers of the channel
+func (s *ChannelState) IsRecipient(addr common.Address) bool {
+	for _, recipient := range s.Recipients {
+		if recipient == addr {
+			return true
+		}
+	}
+	return false
+}
+
+//GetChannelState reads the state of the channel-contract, a Channel or a TokenChannel;
+//there is no code at a destroyed channel, so a channel without code is ChannelDestroyed
+func (ch *ChannelNodeInfo) GetChannelState(channelAddress common.Address) (*ChannelState, error) {
+	return ch.GetChannelStateWithContext(context.Background(), channelAddress)
+}
+
+//GetChannelStateWithContext is GetChannelState which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetChannelStateWithContext(ctx context.Context, channelAddress common.Address) (*ChannelState, error) {
+	return ch.getChannelState(ctx, channelAddress, nil)
+}
+
+//GetChannelStateSince is GetChannelState of a channel-contract which has its code at block since,
+//such as the block its deployment is mined in; without code it is ChannelDestroyed if its closeChannel event
+//is logged from since on or its code is there at since, otherwise ErrChannelNotDeployed is returned.
+//A version 1 channel logs no closeChannel, so the state at since must still be kept by the node for it
+func (ch *ChannelNodeInfo) GetChannelStateSince(channelAddress common.Address, since uint64) (*ChannelState, error) {
+	return ch.GetChannelStateSinceWithContext(context.Background(), channelAddress, since)
+}
+
+//GetChannelStateSinceWithContext is GetChannelStateSince which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetChannelStateSinceWithContext(ctx context.Context, channelAddress common.Address, since uint64) (*ChannelState, error) {
+	return ch.getChannelState(ctx, channelAddress, new(big.Int).SetUint64(since))
+}
+
+//getChannelState reads the state at the latest block, since is nil if the deploy block is not known
+func (ch *ChannelNodeInfo) getChannelState(ctx context.Context, channelAddress common.Address, since *big.Int) (*ChannelState, error) {
+	backend := ch.getBackend()
+
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	//所有读取都固定在同一区块
+	block := head.Number
+	state := &ChannelState{
+		Address:   channelAddress,
+		Block:     block.Uint64(),
+		ChainTime: time.Unix(int64(head.Time), 0),
+	}
+
+	code, err := backend.CodeAt(ctx, channelAddress, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		//selfdestruct之后合约代码为空；知道部署区块时才区分从未部署
+		if since != nil {
+			deployed, err := channelDeployed(ctx, backend, channelAddress, since, block)
+			if err != nil {
+				return nil, err
+			}
+			if !deployed {
+				return nil, ErrChannelNotDeployed
+			}
+		}
+		state.Status = ChannelDestroyed
+		return state, nil
+	}
+
+	channelInstance, err := channel.NewChannel(channelAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{
+		From:        ch.addr,
+		BlockNumber: block,
+		Context:     ctx,
+	}
+
+	startDate, timeOut, sender, recipients, err := channelInstance.GetInfo(opts)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := channelInstance.GetOwner(opts)
+	if err != nil {
+		return nil, err
+	}
+	version, err := channelVersion(ctx, backend, ch.addr, channelAddress, block)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := channelToken(ctx, backend, ch.addr, channelAddress, block)
+	if err != nil {
+		return nil, err
+	}
+	var balance *big.Int
+	if token == (common.Address{}) {
+		balance, err = backend.BalanceAt(ctx, channelAddress, block)
+	} else {
+		balance, err = tokenBalance(ctx, backend, token, channelAddress, block)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state.Sender = sender
+	state.Recipients = recipients
+	state.Owner = owner
+	state.Start = time.Unix(startDate.Int64(), 0)
+	state.Timeout = time.Duration(timeOut.Int64()) * time.Second
+	state.Expiry = time.Unix(startDate.Int64()+timeOut.Int64(), 0)
+	state.Token = token
+	state.Balance = balance
+	state.Version = version
+	state.Status = state.StatusAt(state.ChainTime, ExpiringMargin)
+
+	return state, nil
+}
+
+//channelVersion reads the version of the channel-contract at block;
+//a contract without GetVersion reverts the call or returns nothing, it is version 1
+func channelVersion(ctx context.Context, backend bind.ContractCaller, from, channelAddress common.Address, block *big.Int) (uint16, error) {
+	parsed, err := abi.JSON(strings.NewReader(channel.ChannelABI))
+	if err != nil {
+		return 0, err
+	}
+	data, err := parsed.Pack("GetVersion")
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := backend.CallContract(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &channelAddress,
+		Data: data,
+	}, block)
+	if err != nil {
+		if decodeRevert(err) != nil {
+			return 1, nil
+		}
+		return 0, err
+	}
+	if len(out) == 0 {
+		return 1, nil
+	}
+
+	var version uint16
+	err = parsed.Unpack(&version, "GetVersion", out)
+	return version, err
+}
+
+//channelToken reads the token of a TokenChannel at block;
+//a Channel has no GetToken, the call reverts or returns nothing and the token is zero
+func channelToken(ctx context.Context, backend bind.ContractCaller, from, channelAddress common.Address, block *big.Int) (common.Address, error) {
+	parsed, err := abi.JSON(strings.NewReader(channel.TokenChannelABI))
+	if err != nil {
+		return common.Address{}, err
+	}
+	data, err := parsed.Pack("GetToken")
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	out, err := backend.CallContract(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &channelAddress,
+		Data: data,
+	}, block)
+	if err != nil {
+		if decodeRevert(err) != nil {
+			return common.Address{}, nil
+		}
+		return common.Address{}, err
+	}
+	if len(out) == 0 {
+		return common.Address{}, nil
+	}
+
+	var token common.Address
+	err = parsed.Unpack(&token, "GetToken", out)
+	return token, err
+}
+
+//tokenBalance reads the balance of token held by account at block
+func tokenBalance(ctx context.Context, backend bind.ContractCaller, token, account common.Address, block *big.Int) (*big.Int, error) {
+	tokenInstance, err := channel.NewERC20Caller(token, backend)
+	if err != nil {
+		return nil, err
+	}
+	return tokenInstance.BalanceOf(&bind.CallOpts{
+		BlockNumber: block,
+		Context:     ctx,
+	}, account)
+}
+
+//channelDeployed reports whether the channel without code at block has been there since,
+//by its closeChannel event from since to block or else by its code at since
+func channelDeployed(ctx context.Context, backend ChannelBackend, channelAddress common.Address, since, block *big.Int) (bool, error) {
+	if since.Cmp(block) > 0 {
+		return false, nil
+	}
+
+	filterer, err := channel.NewChannelFilterer(channelAddress, backend)
+	if err != nil {
+		return false, err
+	}
+	//按maxFilterRange分段查询，节点不接受过大的区块范围
+	to := block.Uint64()
+	for start := since.Uint64(); start <= to; start += maxFilterRange {
+		end := start + maxFilterRange - 1
+		if end > to {
+			end = to
+		}
+		closed, err := channelClosed(ctx, filterer, start, end)
+		if err != nil || closed {
+			return closed, err
+		}
+	}
+
+	code, err := backend.CodeAt(ctx, channelAddress, since)
+	if err != nil {
+		return false, err
+	}
+	return len(code) != 0, nil
+}
+
+//channelClosed reports whether a closeChannel event of the channel is in blocks [start, end]
+func channelClosed(ctx context.Context, filterer *channel.ChannelFilterer, start, end uint64) (bool, error) {
+	it, err := filterer.FilterCloseChannel(&bind.FilterOpts{
+		Start:   start,
+		End:     &end,
+		Context: ctx,
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	defer it.Close()
+
+	closed := it.Next()
+	return closed, it.Error()
+}
@@ -0,0 +1,98 @@
+package contracts_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+)
+
+func TestChannelStatus(t *testing.T) {
+	expiry := time.Unix(10000, 0)
+	s := &contracts.ChannelState{Expiry: expiry}
+
+	tests := []struct {
+		now  time.Time
+		want contracts.ChannelStatus
+	}{
+		{expiry.Add(-2 * time.Hour), contracts.ChannelOpen},
+		{expiry.Add(-time.Hour), contracts.ChannelExpiring},
+		{expiry.Add(-time.Second), contracts.ChannelExpiring},
+		{expiry, contracts.ChannelExpired},
+	}
+	for _, tt := range tests {
+		if got := s.StatusAt(tt.now, time.Hour); got != tt.want {
+			t.Errorf("status at %d is %s, want %s", tt.now.Unix(), got, tt.want)
+		}
+	}
+
+	s.Status = contracts.ChannelDestroyed
+	if got := s.StatusAt(expiry.Add(-2*time.Hour), time.Hour); got != contracts.ChannelDestroyed {
+		t.Errorf("destroyed channel is %s", got)
+	}
+}
+
+func TestUndeployedChannelState(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a version 1 channel which logs nothing when it is destroyed
+	v1Addr := common.HexToAddress("0x5678")
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr(key): {Balance: ether},
+		v1Addr:    {Code: eventCode(), Balance: new(big.Int)},
+	}, 100000000)
+	defer sim.Close()
+
+	c, err := contracts.NewCH(addr(key), hexKey(key), contracts.WithBackend(sim))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := c.(*contracts.ChannelNodeInfo)
+	channelAddr := common.HexToAddress("0x1234")
+
+	// without the deploy block a channel without code can only be destroyed
+	state, err := ch.GetChannelState(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != contracts.ChannelDestroyed {
+		t.Fatal("channel without code is", state.Status)
+	}
+
+	// no code at the deploy block and no close event after it: nothing was ever deployed there
+	sim.Commit()
+	head := sim.Blockchain().CurrentBlock().NumberU64()
+	for _, since := range []uint64{head, head + 1} {
+		_, err = ch.GetChannelStateSince(channelAddr, since)
+		if err != contracts.ErrChannelNotDeployed {
+			t.Fatalf("since %d: got %v, want %v", since, err, contracts.ErrChannelNotDeployed)
+		}
+	}
+
+	// its code at the deploy block tells a destroyed version 1 channel
+	tx, err := types.SignTx(types.NewTransaction(0, v1Addr, new(big.Int), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sim.SendTransaction(context.Background(), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	state, err = ch.GetChannelStateSince(v1Addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != contracts.ChannelDestroyed {
+		t.Fatal("destroyed version 1 channel is", state.Status)
+	}
+}
@@ -0,0 +1,217 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+//ErrNoToken a token channel-contract must have a token to pay in
+var ErrNoToken = errors.New("no token for channel")
+
+//DeployTokenChannelContract deploy one channel-contract which pays all of providerAddresses in token,
+//records it in the mapper of every provider like DeployMultiChannelContract, then approves and deposits amount of token;
+//if the deposit fails the channel is still returned, and TopUpTokenChannel can fund it later
+func (ch *ChannelNodeInfo) DeployTokenChannelContract(queryAddress, token common.Address, providerAddresses []common.Address, timeOut, amount *big.Int) (common.Address, []common.Address, error) {
+	return ch.DeployTokenChannelContractWithContext(context.Background(), queryAddress, token, providerAddresses, timeOut, amount)
+}
+
+//DeployTokenChannelContractWithContext is DeployTokenChannelContract which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DeployTokenChannelContractWithContext(ctx context.Context, queryAddress, token common.Address, providerAddresses []common.Address, timeOut, amount *big.Int) (common.Address, []common.Address, error) {
+	var channelAddr common.Address
+	if token == (common.Address{}) {
+		return channelAddr, nil, ErrNoToken
+	}
+
+	recipients := uniqueRecipients(providerAddresses)
+	if len(recipients) == 0 {
+		return channelAddr, nil, ErrNoRecipient
+	}
+	if _, err := ch.getMapper(); err != nil {
+		return channelAddr, nil, err
+	}
+
+	client := ch.getBackend()
+	res, err := SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpDeploy, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		cAddr, tx, _, err := channel.DeployTokenChannel(auth, client, auth.From, token, recipients, timeOut)
+		if cAddr.String() != InvalidAddr {
+			channelAddr = cAddr
+		}
+		return tx, err
+	})
+	if err != nil {
+		return channelAddr, recipients, err
+	}
+	//a dynamic-fee deploy does not give bind the address
+	if res.Receipt != nil && res.Receipt.ContractAddress.String() != InvalidAddr {
+		channelAddr = res.Receipt.ContractAddress
+	}
+	log.Println("token channel contract", channelAddr.String(), "of token", token.String(), "with", recipients, "have been successfuly deployed!")
+
+	//先记录到mapper再存入token，避免资金进入无人知道的合约
+	err = ch.addToMappers(ctx, queryAddress, recipients, channelAddr)
+	if err != nil {
+		return channelAddr, recipients, err
+	}
+
+	if amount != nil && amount.Sign() > 0 {
+		err = ch.TopUpTokenChannelWithContext(ctx, channelAddr, amount)
+		if err != nil {
+			return channelAddr, recipients, err
+		}
+	}
+
+	return channelAddr, recipients, nil
+}
+
+//GetChannelToken returns the token the channel-contract pays in
+func (ch *ChannelNodeInfo) GetChannelToken(channelAddress common.Address) (common.Address, error) {
+	return ch.GetChannelTokenWithContext(context.Background(), channelAddress)
+}
+
+//GetChannelTokenWithContext is GetChannelToken which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetChannelTokenWithContext(ctx context.Context, channelAddress common.Address) (common.Address, error) {
+	channelInstance, err := channel.NewTokenChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return channelInstance.GetToken(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	})
+}
+
+//GetTokenChannelBalance returns the token left in the channel-contract
+func (ch *ChannelNodeInfo) GetTokenChannelBalance(channelAddress common.Address) (*big.Int, error) {
+	return ch.GetTokenChannelBalanceWithContext(context.Background(), channelAddress)
+}
+
+//GetTokenChannelBalanceWithContext is GetTokenChannelBalance which is aborted when ctx is done
+func (ch *ChannelNodeInfo) GetTokenChannelBalanceWithContext(ctx context.Context, channelAddress common.Address) (*big.Int, error) {
+	token, err := ch.GetChannelTokenWithContext(ctx, channelAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenInstance, err := channel.NewERC20(token, ch.getBackend())
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenInstance.BalanceOf(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}, channelAddress)
+}
+
+//TopUpTokenChannel called by user to approve and deposit amount of token into the channel-contract
+func (ch *ChannelNodeInfo) TopUpTokenChannel(channelAddress common.Address, amount *big.Int) error {
+	return ch.TopUpTokenChannelWithContext(context.Background(), channelAddress, amount)
+}
+
+//TopUpTokenChannelWithContext is TopUpTokenChannel which is aborted when ctx is done
+func (ch *ChannelNodeInfo) TopUpTokenChannelWithContext(ctx context.Context, channelAddress common.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrTopUpAmount
+	}
+
+	token, err := ch.GetChannelTokenWithContext(ctx, channelAddress)
+	if err != nil {
+		return err
+	}
+
+	backend := ch.getBackend()
+	tokenInstance, err := channel.NewERC20(token, backend)
+	if err != nil {
+		return err
+	}
+	channelInstance, err := channel.NewTokenChannel(channelAddress, backend)
+	if err != nil {
+		return err
+	}
+
+	//额度不够时才approve
+	allowance, err := tokenInstance.Allowance(&bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}, ch.addr, channelAddress)
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(amount) < 0 {
+		//tokens such as USDT revert an approve which changes one non-zero allowance to another
+		if allowance.Sign() > 0 {
+			_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpApprove, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return tokenInstance.Approve(auth, channelAddress, new(big.Int))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpApprove, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return tokenInstance.Approve(auth, channelAddress, amount)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpDeposit, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.Deposit(auth, amount)
+	})
+	return err
+}
+
+//DemandTokenPayment called by provider to withdraw value of token from the channel-contract with the user's signature
+func (ch *ChannelNodeInfo) DemandTokenPayment(channelAddress common.Address, value, nonce *big.Int, sig []byte) error {
+	return ch.DemandTokenPaymentWithContext(context.Background(), channelAddress, value, nonce, sig)
+}
+
+//DemandTokenPaymentWithContext is DemandTokenPayment which is aborted when ctx is done
+func (ch *ChannelNodeInfo) DemandTokenPaymentWithContext(ctx context.Context, channelAddress common.Address, value, nonce *big.Int, sig []byte) error {
+	channelInstance, err := channel.NewTokenChannel(channelAddress, ch.getBackend())
+	if err != nil {
+		return err
+	}
+
+	opts := &bind.CallOpts{
+		From:    ch.addr,
+		Context: ctx,
+	}
+	used, err := channelInstance.GetNonceValue(opts, ch.addr, nonce)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrNonceUsed
+	}
+	token, err := channelInstance.GetToken(opts)
+	if err != nil {
+		return err
+	}
+
+	hashNew := tokenPaymentHash(channelAddress, token, value, nonce, ch.addr)
+
+	_, err = SendTxWithContext(ctx, ch.getBackend(), ch.signer, nil, ch.policy, OpPay, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return channelInstance.DemandPayment(auth, hashNew, value, nonce, sig)
+	})
+	return err
+}
+
+//tokenPaymentHash (channelAddress, token, value, nonce, recipient)的哈希值, checked by DemandPayment of TokenChannel
+func tokenPaymentHash(channelAddress, token common.Address, value, nonce *big.Int, recipient common.Address) [32]byte {
+	var hashNew [32]byte
+	valueNew := common.LeftPadBytes(value.Bytes(), 32)
+	nonceNew := common.LeftPadBytes(nonce.Bytes(), 32)
+	hash := crypto.Keccak256(channelAddress.Bytes(), token.Bytes(), valueNew, nonceNew, recipient.Bytes()) //32Byte
+	copy(hashNew[:], hash[:32])
+	return hashNew
+}
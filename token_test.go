@@ -0,0 +1,173 @@
+package contracts_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/contracts/channel"
+)
+
+// noReturnTokenBin is an ERC-20 token like USDT: transfer, transferFrom and approve return nothing,
+// approve only sets an allowance from or to 0; the constructor gives the supply to the deployer
+const noReturnTokenBin = "0x608060405234801561001057600080fd5b506040516103fc3803806103fc83398101604081905261002f91610044565b3360009081526020819052604090205561005d565b60006020828403121561005657600080fd5b5051919050565b6103908061006c6000396000f3fe608060405234801561001057600080fd5b50600436106100575760003560e01c8063095ea7b31461005c57806323b872dd1461007157806370a0823114610084578063a9059cbb146100b6578063dd62ed3e146100c9575b600080fd5b61006f61006a36600461025d565b6100f4565b005b61006f61007f366004610287565b610155565b6100a46100923660046102c3565b60006020819052908152604090205481565b60405190815260200160405180910390f35b61006f6100c436600461025d565b6101ec565b6100a46100d73660046102e5565b600160209081526000928352604080842090915290825290205481565b80158061012257503360009081526001602090815260408083206001600160a01b0386168452909152902054155b61012b57600080fd5b3360009081526001602090815260408083206001600160a01b039590951683529390529190912055565b6001600160a01b03831660009081526001602090815260408083203384529091528120805483929061018890849061032e565b90915550506001600160a01b038316600090815260208190526040812080548392906101b590849061032e565b90915550506001600160a01b038216600090815260208190526040812080548392906101e2908490610347565b9091555050505050565b336000908152602081905260408120805483929061020b90849061032e565b90915550506001600160a01b03821660009081526020819052604081208054839290610238908490610347565b90915550505050565b80356001600160a01b038116811461025857600080fd5b919050565b6000806040838503121561027057600080fd5b61027983610241565b946020939093013593505050565b60008060006060848603121561029c57600080fd5b6102a584610241565b92506102b360208501610241565b9150604084013590509250925092565b6000602082840312156102d557600080fd5b6102de82610241565b9392505050565b600080604083850312156102f857600080fd5b61030183610241565b915061030f60208401610241565b90509250929050565b634e487b7160e01b600052601160045260246000fd5b8181038181111561034157610341610318565b92915050565b808201808211156103415761034161031856fea2646970667358221220c6d2142c765aa1d1765829058ede8c69efb47180dea12f08e4a896f0999e016b64736f6c63430008150033"
+
+const noReturnTokenABI = `[{"inputs":[{"name":"supply","type":"uint256"}],"stateMutability":"nonpayable","type":"constructor"}]`
+
+// deployToken deploys a noReturnToken of supply owned by the payer
+func (tc *testChain) deployToken(supply *big.Int) common.Address {
+	parsed, err := abi.JSON(strings.NewReader(noReturnTokenABI))
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	auth := bind.NewKeyedTransactor(tc.payer)
+	auth.GasLimit = 5000000
+	_, tx, _, err := bind.DeployContract(auth, parsed, common.FromHex(noReturnTokenBin), tc.sim, supply)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token, err := bind.WaitDeployed(ctx, tc.sim, tx)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	return token
+}
+
+func (tc *testChain) tokenBalance(token, account common.Address) *big.Int {
+	tokenInstance, err := channel.NewERC20(token, tc.sim)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	bal, err := tokenInstance.BalanceOf(nil, account)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	return bal
+}
+
+func TestTokenChannelSender(t *testing.T) {
+	tc := newTestChain(t)
+	token := tc.deployToken(big.NewInt(10000))
+	parsed, err := abi.JSON(strings.NewReader(channel.TokenChannelABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := parsed.Pack("", addr(tc.other), token, []common.Address{addr(tc.provider)}, big.NewInt(testTimeOut))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := tc.revert(addr(tc.payer), nil, append(common.FromHex(channel.TokenChannelBin), args...))
+	if !strings.Contains(got, "illegal sender") {
+		t.Fatalf("another sender: got %q", got)
+	}
+}
+
+func TestTokenChannelLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tc := newTestChain(t)
+	supply := big.NewInt(10000)
+	token := tc.deployToken(supply)
+	payer := tc.node(tc.payer, contracts.WithMapper(&memMapper{channels: make(map[string][]common.Address)}))
+	provider := tc.node(tc.provider)
+
+	// transferFrom of Deposit returns nothing
+	channelAddr, _, err := payer.DeployTokenChannelContract(addr(tc.other), token, []common.Address{addr(tc.provider)}, big.NewInt(testTimeOut), big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.tokenBalance(token, channelAddr).Int64() != 1000 {
+		t.Fatal("deposit is not in the channel")
+	}
+
+	// the state of a token channel has its token and the balance in it
+	state, err := payer.GetChannelState(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Token != token || state.Balance.Int64() != 1000 || state.Version != contracts.ChannelVersion || state.Status != contracts.ChannelOpen {
+		t.Fatalf("state of token channel: %+v", state)
+	}
+	deployedAt := state.Block
+
+	ix, err := contracts.OpenChannelIndexer(dir, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	ix.StartBlock = deployedAt
+	err = ix.AddChannel(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// transfer of DemandPayment returns nothing
+	value, nonce := big.NewInt(300), big.NewInt(1)
+	hash := crypto.Keccak256(channelAddr.Bytes(), token.Bytes(), common.LeftPadBytes(value.Bytes(), 32), common.LeftPadBytes(nonce.Bytes(), 32), addr(tc.provider).Bytes())
+	sig, err := crypto.Sign(hash, tc.payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.DemandTokenPayment(channelAddr, value, nonce, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.tokenBalance(token, addr(tc.provider)).Int64() != 300 {
+		t.Fatal("payment is not made")
+	}
+
+	// so does the transfer of the tokens left back to the payer
+	tc.skipTime((testTimeOut + 3600) * time.Second)
+	err = payer.ChannelTimeout(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.tokenBalance(token, addr(tc.payer)).Int64() != supply.Int64()-300 {
+		t.Fatal("tokens left are not back to the payer")
+	}
+
+	// its closeChannel event tells it is destroyed
+	state, err = payer.GetChannelStateSince(channelAddr, deployedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != contracts.ChannelDestroyed {
+		t.Fatal("destroyed token channel is", state.Status)
+	}
+	err = ix.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs, err := ix.History(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, ev := range evs {
+		kinds = append(kinds, ev.Type)
+	}
+	if len(evs) < 2 {
+		t.Fatalf("history of token channel: %v", kinds)
+	}
+	last := evs[len(evs)-1]
+	if kinds[len(kinds)-2] != contracts.EventPay || last.Type != contracts.EventDestroy || last.Value.Int64() != 700 || last.TxHash == (common.Hash{}) {
+		t.Fatalf("history of token channel: %v", kinds)
+	}
+}
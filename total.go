@@ -0,0 +1,254 @@
+package role
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gogo/protobuf/proto"
+	"github.com/memoio/go-mefs/contracts"
+	mpb "github.com/memoio/go-mefs/pb"
+	"github.com/memoio/go-mefs/utils/address"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//ErrVoucherTotal the total of a cumulative voucher is not larger than the last one of the same recipient
+var ErrVoucherTotal = errors.New("voucher total is not larger than the last one")
+
+const totalPrefix = "total/" //total/channel/recipient -> the last cumulative Voucher
+
+//SignTotalVoucher signs a cumulative voucher for recipient whose total is the last one plus value;
+//it refuses when the totals of the channel minus what is withdrawn would be more than the channel balance.
+//A channel should be paid either by SignVoucher or by SignTotalVoucher, the two are not checked against each other
+func (l *VoucherLedger) SignTotalVoucher(channelID string, signer contracts.Signer, recipient common.Address, value *big.Int) (*Voucher, error) {
+	if value == nil || value.Sign() <= 0 {
+		return nil, ErrVoucherValue
+	}
+
+	channelAddr, err := address.GetAddressFromID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance *big.Int
+	if l.chain != nil {
+		balance, err = l.chain.GetChannelBalance(channelAddr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	total := new(big.Int).Set(value)
+	latest, err := l.latestTotal(channelAddr, recipient)
+	if err == nil {
+		total.Add(total, latest.Value)
+	} else if err != ErrNoVoucher {
+		return nil, err
+	}
+
+	if balance != nil {
+		owed, err := l.owed(channelAddr, recipient, total)
+		if err != nil {
+			return nil, err
+		}
+		if owed.Cmp(balance) > 0 {
+			return nil, ErrVoucherOverdraw
+		}
+	}
+
+	mes, err := SignForChannelTotalWithSigner(channelID, signer, recipient, total)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Voucher{
+		Channel:    channelAddr,
+		Recipient:  recipient,
+		Value:      total,
+		Sign:       mes,
+		Cumulative: true,
+	}
+	val, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = l.db.Put(totalKey(channelAddr, recipient), val, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+//LatestTotal returns the last cumulative voucher signed for recipient on the channel
+func (l *VoucherLedger) LatestTotal(channelAddr, recipient common.Address) (*Voucher, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	return l.latestTotal(channelAddr, recipient)
+}
+
+func (l *VoucherLedger) latestTotal(channelAddr, recipient common.Address) (*Voucher, error) {
+	return getTotal(l.db, channelAddr, recipient)
+}
+
+//owed returns what the cumulative vouchers of the channel still owe, with total as the one of recipient
+func (l *VoucherLedger) owed(channelAddr, recipient common.Address, total *big.Int) (*big.Int, error) {
+	totals := map[common.Address]*big.Int{recipient: total}
+	iter := l.db.NewIterator(util.BytesPrefix([]byte(totalPrefix+channelAddr.Hex()+"/")), nil)
+	defer iter.Release()
+	for iter.Next() {
+		v := new(Voucher)
+		err := json.Unmarshal(iter.Value(), v)
+		if err != nil {
+			return nil, err
+		}
+		if v.Recipient != recipient {
+			totals[v.Recipient] = v.Value
+		}
+	}
+	if iter.Error() != nil {
+		return nil, iter.Error()
+	}
+
+	sum := new(big.Int)
+	for r, t := range totals {
+		paid, err := l.chain.GetWithdrawn(channelAddr, r)
+		if err != nil {
+			return nil, err
+		}
+		if t.Cmp(paid) > 0 {
+			sum.Add(sum, new(big.Int).Sub(t, paid))
+		}
+	}
+	return sum, nil
+}
+
+//ReceiveTotal validates a marshaled mpb.ChannelSign of a cumulative voucher and keeps it
+//if its total is larger than the last one; only the last one needs to be redeemed, by SettlePayment
+func (w *VoucherWallet) ReceiveTotal(mes []byte) (*Voucher, error) {
+	cSign := new(mpb.ChannelSign)
+	err := proto.Unmarshal(mes, cSign)
+	if err != nil {
+		return nil, err
+	}
+
+	if common.BytesToAddress(cSign.GetRecipient()) != w.addr {
+		return nil, ErrVoucherRecipient
+	}
+	if !VerifyChannelTotalSign(cSign) {
+		return nil, ErrVoucherSign
+	}
+
+	channelAddr, err := address.GetAddressFromID(cSign.GetChannelID())
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.checkChannel(cSign, channelAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Voucher{
+		Channel:    channelAddr,
+		Recipient:  w.addr,
+		Value:      new(big.Int).SetBytes(cSign.GetValue()),
+		Sign:       mes,
+		Cumulative: true,
+	}
+	if v.Value.Sign() <= 0 {
+		return nil, ErrVoucherValue
+	}
+
+	//未提取的部分不能超过余额
+	paid, err := w.chain.GetWithdrawn(channelAddr, w.addr)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := w.chain.GetChannelBalance(channelAddr)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Sub(v.Value, paid).Cmp(balance) > 0 {
+		return nil, ErrVoucherOverBalance
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	latest, err := getTotal(w.db, channelAddr, w.addr)
+	if err != nil && err != ErrNoVoucher {
+		return nil, err
+	}
+	if latest != nil && v.Value.Cmp(latest.Value) <= 0 {
+		return nil, ErrVoucherTotal
+	}
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = w.db.Put(totalKey(channelAddr, w.addr), val, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+//LatestTotal returns the last cumulative voucher received from the channel, the one to redeem
+func (w *VoucherWallet) LatestTotal(channelAddr common.Address) (*Voucher, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	return getTotal(w.db, channelAddr, w.addr)
+}
+
+//MarkTotalSettled marks the last cumulative voucher of the channel as settled if its total is total,
+//a larger one received since is still to be settled
+func (w *VoucherWallet) MarkTotalSettled(channelAddr common.Address, total *big.Int) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	v, err := getTotal(w.db, channelAddr, w.addr)
+	if err != nil {
+		return err
+	}
+	if v.Value.Cmp(total) != 0 {
+		return nil
+	}
+	v.Redeemed = true
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.db.Put(totalKey(channelAddr, w.addr), val, nil)
+}
+
+func getTotal(db *leveldb.DB, channelAddr, recipient common.Address) (*Voucher, error) {
+	val, err := db.Get(totalKey(channelAddr, recipient), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoVoucher
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(Voucher)
+	err = json.Unmarshal(val, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func totalKey(channelAddr, recipient common.Address) []byte {
+	return []byte(totalPrefix + channelAddr.Hex() + "/" + recipient.Hex())
+}
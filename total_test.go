@@ -0,0 +1,125 @@
+package role
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+func TestTotalVoucher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := contracts.NewECDSASigner(sk)
+
+	channelAddr := common.HexToAddress("0x1")
+	provider := common.HexToAddress("0x2")
+	other := common.HexToAddress("0x3")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{
+		sender:     payer.Address(),
+		recipients: []common.Address{provider, other},
+		balance:    big.NewInt(50),
+		withdrawn:  make(map[common.Address]*big.Int),
+	}
+	l, err := OpenVoucherLedger(dir+"/ledger", chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	w, err := OpenVoucherWallet(dir+"/wallet", provider, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// every voucher states the total owed so far
+	var sent []*Voucher
+	for _, value := range []int64{10, 15} {
+		v, err := l.SignTotalVoucher(channelID, payer, provider, big.NewInt(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, v)
+	}
+	if sent[1].Value.Int64() != 25 || !sent[1].Cumulative {
+		t.Fatalf("got total %s, want 25", sent[1].Value)
+	}
+	_, err = l.SignTotalVoucher(channelID, payer, other, big.NewInt(30))
+	if err != ErrVoucherOverdraw {
+		t.Fatal("voucher beyond the balance is signed:", err)
+	}
+
+	// settled totals are paid out of the balance already
+	chain.withdrawn[provider] = big.NewInt(25)
+	chain.balance = big.NewInt(25)
+	_, err = l.SignTotalVoucher(channelID, payer, other, big.NewInt(25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := l.LatestTotal(channelAddr, provider)
+	if err != nil || latest.Value.Int64() != 25 {
+		t.Fatal("wrong latest total", latest, err)
+	}
+
+	chain.withdrawn[provider] = new(big.Int)
+	chain.balance = big.NewInt(50)
+	_, err = w.ReceiveTotal(sent[1].Sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.ReceiveTotal(sent[0].Sign)
+	if err != ErrVoucherTotal {
+		t.Fatalf("got %v, want %v", err, ErrVoucherTotal)
+	}
+	v, err := w.LatestTotal(channelAddr)
+	if err != nil || v.Value.Int64() != 25 {
+		t.Fatal("wrong latest total", v, err)
+	}
+
+	// a voucher for another recipient or not of the payer is refused
+	mes, err := SignForChannelTotalWithSigner(channelID, payer, other, big.NewInt(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.ReceiveTotal(mes)
+	if err != ErrVoucherRecipient {
+		t.Fatalf("got %v, want %v", err, ErrVoucherRecipient)
+	}
+	_, err = w.ReceiveTotal(sent[0].Sign[:len(sent[0].Sign)-1])
+	if err == nil {
+		t.Fatal("broken voucher is received")
+	}
+
+	// only the part not withdrawn has to be in the balance
+	mes, err = SignForChannelTotalWithSigner(channelID, payer, provider, big.NewInt(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.ReceiveTotal(mes)
+	if err != ErrVoucherOverBalance {
+		t.Fatalf("got %v, want %v", err, ErrVoucherOverBalance)
+	}
+	chain.withdrawn[provider] = big.NewInt(25)
+	_, err = w.ReceiveTotal(mes)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
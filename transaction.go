@@ -0,0 +1,393 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultGasMultiplier = 1.2             //safety margin on the estimated gas
+	defaultMineTimeout   = 3 * time.Minute //time to wait for a receipt before the transaction is rebuilt
+)
+
+//ErrTxNotMined no receipt of the transaction within TxPolicy.MineTimeout, it is dropped or still pending
+var ErrTxNotMined = errors.New("transaction is not mined in time")
+
+//TxPolicy retry and gas escalation policy used by SendTx
+type TxPolicy struct {
+	SendRetry     int           //times to resend when the node rejects the transaction
+	CheckRetry    int           //times to rebuild when the transaction fails on chain
+	RetrySleep    time.Duration //sleep between two sending attempts
+	MineTimeout   time.Duration //time to wait for the receipt before ErrTxNotMined, 0 waits until ctx is done
+	GasPrice      *big.Int      //gas price of the first attempt, nil asks the node for it
+	MaxGasPrice   *big.Int      //gas price is never above it, nil for no cap
+	GasTipCap     *big.Int      //tip of the first dynamic-fee attempt, nil asks the node for it
+	MaxFeePerGas  *big.Int      //fee cap of dynamic-fee transactions is never above it, nil for no cap
+	GasBump       *big.Int      //added to the gas price, or tip and fee cap, every time the transaction is rebuilt, see bumpFee
+	GasLimit      uint64        //gas limit when GasMultiplier is 0 or the estimation fails
+	GasMultiplier float64       //gas limit is the estimated gas times it, 0 always uses GasLimit
+	ChainID       *big.Int      //chain ID transactions are signed for by EIP-155, nil asks the backend for it
+	Nonces        *NonceManager //hands out the nonces of new transactions, nil lets the node choose
+	Watchdog      *TxWatchdog   //tracks and replaces the legacy transactions sent, nil for none
+	Confirmations Confirmations //blocks on top of the receipt before SendTx returns, per operation
+	OnReorg       ReorgFunc     //called when a receipt waited for confirmations leaves its block
+}
+
+//DefaultTxPolicy the policy used by the channel methods unless set otherwise
+func DefaultTxPolicy() *TxPolicy {
+	return &TxPolicy{
+		SendRetry:     sendTransactionRetryCount,
+		CheckRetry:    checkTxRetryCount,
+		RetrySleep:    retryTxSleepTime,
+		MineTimeout:   defaultMineTimeout,
+		GasBump:       big.NewInt(defaultGasPrice),
+		GasLimit:      defaultGasLimit,
+		GasMultiplier: defaultGasMultiplier,
+		Nonces:        defaultNonces,
+		Confirmations: DefaultConfirmations,
+	}
+}
+
+//TxResult the outcome of SendTx
+type TxResult struct {
+	TxHash    common.Hash    //hash of the last transaction sent
+	GasPrice  *big.Int       //gas price of the last transaction sent, its fee cap if it is a dynamic-fee one
+	GasTipCap *big.Int       //tip of the last transaction sent, nil if it is a legacy one
+	Attempts  int            //number of transactions handed to the node
+	Receipt   *types.Receipt //nil if an earlier pending transaction was executed instead
+}
+
+//ChannelBackend the chain access needed to call and deploy the channel-contract
+type ChannelBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+//TxBuilder builds and sends one transaction with the given auth
+type TxBuilder func(auth *bind.TransactOpts) (*types.Transaction, error)
+
+//SendTx sends the transaction built by build and signed by signer until it succeeds on chain or policy gives up;
+//through a backend which meets RPCCaller on a chain with a base fee it is sent as an EIP-1559 DynamicFeeTx,
+//otherwise as a legacy one; a transaction not mined in time is rebuilt with the same nonce and bumped fees,
+//one mined but reverted has used its nonce and is sent again with a new one,
+//unless the revert has a reason, then the error of the reason is returned (see revert.go);
+//backend nil means the node at EndPoint
+func SendTx(backend ChannelBackend, signer Signer, value *big.Int, policy *TxPolicy, name string, build TxBuilder) (*TxResult, error) {
+	return SendTxWithContext(context.Background(), backend, signer, value, policy, name, build)
+}
+
+//SendTxWithContext is SendTx which stops retrying and returns ctx.Err() once ctx is done
+func SendTxWithContext(ctx context.Context, backend ChannelBackend, signer Signer, value *big.Int, policy *TxPolicy, name string, build TxBuilder) (*TxResult, error) {
+	if policy == nil {
+		policy = DefaultTxPolicy()
+	}
+
+	if backend == nil {
+		backend = getClient(EndPoint)
+	}
+	txSigner, err := chainSigner(ctx, backend, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	feeCaller, dynChainID, err := dynamicFeeChain(ctx, backend, policy)
+	if err != nil {
+		return nil, err
+	}
+	dynamic := feeCaller != nil
+
+	check := func(tx *types.Transaction, hash common.Hash) (*types.Receipt, error) {
+		return waitTx(ctx, backend, hash, policy.MineTimeout)
+	}
+	//the watchdog may replace the transaction, wait for whichever version is mined;
+	//it replaces legacy transactions only
+	if policy.Watchdog != nil && signer != nil && !dynamic {
+		check = func(tx *types.Transaction, hash common.Hash) (*types.Receipt, error) {
+			return policy.Watchdog.Wait(ctx, signer.Address(), tx)
+		}
+	}
+
+	log.Println("begin call " + name + "...")
+	res := &TxResult{}
+	var tx *types.Transaction //last transaction sent, the legacy form bind makes if it is sent as dyn
+	var dyn *DynamicFeeTx     //last transaction sent if it is a dynamic-fee one
+	var sent []common.Hash    //all versions sent with the nonce of tx
+	rebuild := false          //replace tx with the same nonce
+	retryCount := 0
+	checkRetryCount := 0
+	for {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+
+		auth, errMA := makeSignerAuth(signer, txSigner, value, nil, nil, policy.GasLimit)
+		if errMA != nil {
+			return res, errMA
+		}
+		auth.Context = ctx
+
+		var base, tip *big.Int
+		if dynamic {
+			base, err = baseFee(ctx, feeCaller)
+			if err != nil {
+				return res, err
+			}
+			if base == nil {
+				base = new(big.Int)
+			}
+		}
+
+		//动态费用交易的GasPrice即fee cap
+		if rebuild {
+			auth.Nonce = big.NewInt(int64(tx.Nonce()))
+			auth.GasLimit = tx.Gas()
+			if dyn != nil {
+				tip, auth.GasPrice, errMA = bumpGasFees(dyn.GasTipCap, dyn.GasFeeCap, base, policy)
+			} else {
+				auth.GasPrice, errMA = bumpGasPrice(tx.GasPrice(), policy)
+			}
+			if errMA != nil {
+				return res, errMA
+			}
+			log.Println("rebuild transaction... nonce is ", auth.Nonce, " gasPrice is ", auth.GasPrice, " tip is ", tip)
+		} else {
+			if dynamic {
+				tip, auth.GasPrice, err = gasFees(ctx, feeCaller, base, policy)
+			} else {
+				auth.GasPrice, err = gasPrice(ctx, backend, policy)
+			}
+			if err != nil {
+				return res, err
+			}
+			auth.GasLimit, err = gasLimit(ctx, backend, auth.From, value, auth.GasPrice, policy, name, build)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		//新交易从NonceManager取nonce，重建的交易沿用原nonce
+		var release func(sent bool)
+		if auth.Nonce == nil && policy.Nonces != nil {
+			nonce, rel, errNonce := policy.Nonces.Acquire(ctx, backend, auth.From)
+			if errNonce != nil {
+				return res, errNonce
+			}
+			auth.Nonce = new(big.Int).SetUint64(nonce)
+			release = rel
+		}
+
+		var sentLegacy *types.Transaction
+		var sentDyn *DynamicFeeTx
+		if dynamic {
+			dynamicFeeAuth(auth, feeCaller, signer, dynChainID, tip, auth.GasPrice, func(legacy *types.Transaction, tx *DynamicFeeTx) {
+				sentLegacy, sentDyn = legacy, tx
+			})
+		}
+
+		res.Attempts++
+		ntx, errSend := build(auth)
+		if errSend == errDynamicSent {
+			ntx, errSend = sentLegacy, nil
+		}
+		if release != nil {
+			release(errSend == nil)
+		}
+		if errSend != nil {
+			err = errSend
+			retryCount++
+			log.Println(name+" Err:", err)
+			if err.Error() == core.ErrNonceTooLow.Error() && rebuild {
+				//nonce已上链，只有我们发出的某个版本成功才算成功
+				receipt, errReceipt := minedReceipt(ctx, backend, sent)
+				if errReceipt != nil {
+					return res, errReceipt
+				}
+				if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+					log.Println("previously pending transaction has successfully executed")
+					res.TxHash = receipt.TxHash
+					res.Receipt = receipt
+					break
+				}
+				if receipt != nil {
+					if errRevert := simulateTx(ctx, backend, auth.From, tx); errRevert != nil {
+						return res, errRevert
+					}
+					log.Println("previously pending transaction fails, send it with a new nonce")
+				} else {
+					log.Println("nonce", tx.Nonce(), "is taken by another transaction, send it with a new nonce")
+				}
+				if policy.Nonces != nil {
+					if errReset := policy.Nonces.Reset(ctx, backend, auth.From); errReset != nil {
+						return res, errReset
+					}
+				}
+				rebuild = false
+				sent = nil
+			}
+			//the node refuses a call which reverts, sending it again does not help
+			if errRevert := decodeRevert(err); errRevert != nil {
+				return res, errRevert
+			}
+			if retryCount > policy.SendRetry {
+				return res, err
+			}
+			if !sleepWithContext(ctx, policy.RetrySleep) {
+				return res, ctx.Err()
+			}
+			continue
+		}
+
+		tx, dyn = ntx, sentDyn
+		hash := tx.Hash()
+		res.GasTipCap = nil
+		if dyn != nil {
+			hash, err = dyn.Hash()
+			if err != nil {
+				return res, err
+			}
+			res.GasTipCap = dyn.GasTipCap
+		}
+		sent = append(sent, hash)
+		res.TxHash = hash
+		res.GasPrice = tx.GasPrice()
+		if policy.Watchdog != nil && dyn == nil {
+			errTrack := policy.Watchdog.Track(ctx, name, signer, tx)
+			if errTrack != nil {
+				log.Println("track "+name+" transaction fails:", errTrack)
+			}
+		}
+
+		res.Receipt, err = check(tx, hash)
+		if err != nil {
+			checkRetryCount++
+			log.Println(name+" transaction fails", err)
+			if err == ErrTxCancelled || ctx.Err() != nil {
+				return res, err
+			}
+
+			//an earlier version may be mined instead of tx
+			receipt := res.Receipt
+			if receipt == nil {
+				var errReceipt error
+				receipt, errReceipt = minedReceipt(ctx, backend, sent)
+				if errReceipt != nil {
+					log.Println("get receipts of "+name+" transaction fails:", errReceipt)
+				}
+			}
+			if receipt != nil && receipt.Status == types.ReceiptStatusSuccessful {
+				res.TxHash = receipt.TxHash
+				res.Receipt = receipt
+				break
+			}
+
+			if receipt != nil {
+				//mined but reverted: the nonce is used, a reverted transaction is not rebuilt with more gas
+				if errRevert := simulateTx(ctx, backend, auth.From, tx); errRevert != nil {
+					return res, errRevert
+				}
+				rebuild = false
+				sent = nil
+			} else {
+				//dropped or still pending, replace it
+				rebuild = true
+			}
+			if checkRetryCount > policy.CheckRetry {
+				return res, err
+			}
+			continue
+		}
+		break
+	}
+
+	if res.Receipt == nil {
+		receipt, err := backend.TransactionReceipt(ctx, res.TxHash)
+		if err == nil {
+			res.Receipt = receipt
+		}
+	}
+
+	if depth := policy.Confirmations.depth(name); depth > 0 && res.Receipt != nil {
+		res.Receipt, err = confirmTx(ctx, backend, res.Receipt, depth, name, policy.OnReorg)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	log.Println("you have called " + name + " successfully!")
+	return res, nil
+}
+
+//minedReceipt returns the receipt of the version of a transaction which is mined, nil if none is;
+//versions are the hashes of the versions
+func minedReceipt(ctx context.Context, backend bind.DeployBackend, versions []common.Hash) (*types.Receipt, error) {
+	for _, v := range versions {
+		receipt, err := backend.TransactionReceipt(ctx, v)
+		if err == ethereum.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+	}
+	return nil, nil
+}
+
+//sleepWithContext sleeps for d, returns false if ctx is done before that
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+//waitTx waits until the transaction of hash is mined by backend, for at most timeout if it is not 0;
+//a reverted transaction gives ErrTxFail, no receipt in time ErrTxNotMined
+func waitTx(ctx context.Context, backend bind.DeployBackend, hash common.Hash, timeout time.Duration) (*types.Receipt, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+
+	ticker := time.NewTicker(waitReceiptInterval)
+	defer ticker.Stop()
+	for {
+		receipt, err := backend.TransactionReceipt(ctx, hash)
+		if err == nil && receipt != nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return receipt, ErrTxFail
+			}
+			return receipt, nil
+		}
+		if err != nil && err != ethereum.NotFound {
+			log.Println("get receipt of transaction", hash.Hex(), "fails:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, ErrTxNotMined
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package contracts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// dropBackend loses the first drop transactions sent to it, as if they fell out of the pool
+type dropBackend struct {
+	*backends.SimulatedBackend
+	drop   int
+	sent   []*types.Transaction
+	onDrop func() // called after a transaction is lost
+}
+
+func (b *dropBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(1337)), tx)
+	if err != nil {
+		return err
+	}
+	// the simulated backend panics on a used nonce, a node refuses it
+	nonce, err := b.PendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < nonce {
+		return core.ErrNonceTooLow
+	}
+
+	b.sent = append(b.sent, tx)
+	if len(b.sent) <= b.drop {
+		if b.onDrop != nil {
+			b.onDrop()
+		}
+		return nil
+	}
+	return b.SimulatedBackend.SendTransaction(ctx, tx)
+}
+
+// newDropBackend mines every 10ms until the test ends
+func newDropBackend(t *testing.T, sk *ecdsa.PrivateKey, drop int) *dropBackend {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(sk.PublicKey): {Balance: big.NewInt(1000000000000000000)},
+		invalidAddr:                          {Code: []byte{0xfe}, Balance: new(big.Int)},
+		channelAdminAddr:                     {Code: common.FromHex(channelAdminCode), Balance: new(big.Int)},
+	}, 100000000)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				sim.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+		sim.Close()
+	})
+
+	return &dropBackend{SimulatedBackend: sim, drop: drop}
+}
+
+// invalidAddr has code which fails every call to it without a reason
+var invalidAddr = common.HexToAddress("0x3000")
+
+// channelAdminAddr has a stand-in of the adminOwned contract Channel.sol asks for the banned version,
+// which is 0 so that channels can be deployed
+var channelAdminAddr = common.HexToAddress("0x8026796Fd7cE63EAe824314AA5bacF55643e893d")
+
+const channelAdminCode = "0x60005460005260206000f3"
+
+// transfer sends nothing to 'to', the simplest transaction SendTx can be given
+func transfer(backend ChannelBackend, to common.Address) TxBuilder {
+	return func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := auth.Signer(types.HomesteadSigner{}, auth.From, types.NewTransaction(auth.Nonce.Uint64(), to, new(big.Int), auth.GasLimit, auth.GasPrice, nil))
+		if err != nil {
+			return nil, err
+		}
+		return tx, backend.SendTransaction(auth.Context, tx)
+	}
+}
+
+func testPolicy() *TxPolicy {
+	return &TxPolicy{
+		CheckRetry:  2,
+		RetrySleep:  time.Millisecond,
+		MineTimeout: 20 * time.Millisecond,
+		GasPrice:    big.NewInt(1),
+		GasBump:     big.NewInt(1),
+		GasLimit:    21000,
+		ChainID:     big.NewInt(1337),
+		Nonces:      NewNonceManager(),
+	}
+}
+
+func TestSendTxNotMined(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newDropBackend(t, sk, 100)
+	to := common.HexToAddress("0x2000")
+
+	res, err := SendTx(backend, NewECDSASigner(sk), nil, testPolicy(), "test", transfer(backend, to))
+	if err != ErrTxNotMined {
+		t.Fatalf("got %v, want %v", err, ErrTxNotMined)
+	}
+	if res.Attempts != 3 || len(backend.sent) != 3 {
+		t.Fatalf("got %d attempts, want 3", res.Attempts)
+	}
+	for i, tx := range backend.sent {
+		if !tx.Protected() || tx.ChainId().Int64() != 1337 {
+			t.Fatalf("attempt %d is not signed for the chain", i)
+		}
+		if tx.Nonce() != 0 || tx.GasPrice().Int64() != int64(i+1) {
+			t.Fatalf("attempt %d is not a replacement: nonce %d, gasPrice %s", i, tx.Nonce(), tx.GasPrice())
+		}
+	}
+
+	// without MineTimeout the wait ends with ctx
+	policy := testPolicy()
+	policy.MineTimeout = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = SendTxWithContext(ctx, backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, to))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSendTxDropped(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newDropBackend(t, sk, 1)
+
+	policy := testPolicy()
+	policy.MineTimeout = 2 * time.Second
+	res, err := SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, common.HexToAddress("0x2000")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Attempts != 2 || res.Receipt == nil || res.Receipt.TxHash != backend.sent[1].Hash() {
+		t.Fatal("dropped transaction is not replaced:", res.Attempts, res.Receipt)
+	}
+	if backend.sent[1].Nonce() != backend.sent[0].Nonce() {
+		t.Fatal("replacement does not reuse the nonce")
+	}
+}
+
+func TestSendTxReverted(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newDropBackend(t, sk, 0)
+
+	policy := testPolicy()
+	policy.MineTimeout = 2 * time.Second
+	res, err := SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, invalidAddr))
+	if err != ErrTxFail {
+		t.Fatalf("got %v, want %v", err, ErrTxFail)
+	}
+	if res.Attempts != 3 {
+		t.Fatalf("got %d attempts, want 3", res.Attempts)
+	}
+	// a mined transaction has used its nonce, it is not replaced
+	for i, tx := range backend.sent {
+		if tx.Nonce() != uint64(i) {
+			t.Fatalf("attempt %d reuses a mined nonce: %d", i, tx.Nonce())
+		}
+	}
+}
+
+func TestSendTxNonceTaken(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newDropBackend(t, sk, 1)
+	to := common.HexToAddress("0x2000")
+
+	// another transaction takes the nonce of the lost one
+	var other *types.Transaction
+	backend.onDrop = func() {
+		other, err = types.SignTx(types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, sk)
+		if err == nil {
+			err = backend.SimulatedBackend.SendTransaction(context.Background(), other)
+		}
+	}
+
+	policy := testPolicy()
+	policy.MineTimeout = 2 * time.Second
+	policy.SendRetry = 1 // the node refuses the replacement once
+	res, errSend := SendTx(backend, NewECDSASigner(sk), nil, policy, "test", transfer(backend, to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errSend != nil {
+		t.Fatal(errSend)
+	}
+	if res.Receipt == nil || res.Receipt.TxHash == other.Hash() {
+		t.Fatal("transaction of another sender is taken as ours")
+	}
+	last := backend.sent[len(backend.sent)-1]
+	if res.Receipt.TxHash != last.Hash() || last.Nonce() != 1 {
+		t.Fatal("transaction is not sent again with a new nonce:", last.Nonce())
+	}
+}
@@ -0,0 +1,320 @@
+package role
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gogo/protobuf/proto"
+	"github.com/memoio/go-mefs/contracts"
+	mpb "github.com/memoio/go-mefs/pb"
+	"github.com/memoio/go-mefs/utils/address"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//ErrVoucherNonce the nonce of a voucher is not larger than the last one signed for the same recipient
+var ErrVoucherNonce = errors.New("voucher nonce is not larger than the last signed one")
+
+//ErrVoucherValue the value of a voucher is not positive
+var ErrVoucherValue = errors.New("voucher value must be positive")
+
+//ErrNoVoucher no voucher is recorded
+var ErrNoVoucher = errors.New("no voucher")
+
+//ErrVoucherOverdraw the channel balance cannot pay a new voucher on top of the unredeemed ones
+var ErrVoucherOverdraw = errors.New("voucher value exceeds the channel balance left to sign")
+
+const (
+	voucherPrefix = "voucher/" //voucher/channel/recipient/nonce -> Voucher
+	latestPrefix  = "latest/"  //latest/channel/recipient -> nonce of the last voucher
+)
+
+//Voucher a signed payment of a channel-contract to one recipient
+type Voucher struct {
+	Channel   common.Address `json:"channel"`
+	Recipient common.Address `json:"recipient"`
+	Value     *big.Int       `json:"value"`
+	Nonce     *big.Int       `json:"nonce"`
+	Sign      []byte         `json:"sign"` //marshaled mpb.ChannelSign
+	Redeemed  bool           `json:"redeemed"`
+
+	Cumulative bool `json:"cumulative,omitempty"` //Value is the total owed so far and Nonce is nil, see SettlePayment
+}
+
+//Sig returns the signature inside the voucher, as DemandPayment takes it
+func (v *Voucher) Sig() ([]byte, error) {
+	cSign := new(mpb.ChannelSign)
+	err := proto.Unmarshal(v.Sign, cSign)
+	if err != nil {
+		return nil, err
+	}
+	return cSign.GetSig(), nil
+}
+
+//VoucherLedger payer-side record of every voucher signed, kept on disk;
+//DemandPayment pays each voucher on its own, so what a channel owes is the sum of its unredeemed vouchers.
+//The vouchers redeemed on chain are marked by Reconcile, SignVoucher does it before every check
+type VoucherLedger struct {
+	lk    sync.Mutex
+	db    *leveldb.DB
+	chain ChannelBalance //nil means vouchers are signed without checking the balance
+}
+
+//OpenVoucherLedger opens or creates the ledger stored in dir,
+//SignVoucher checks the channel balance read from chain
+func OpenVoucherLedger(dir string, chain ChannelBalance) (*VoucherLedger, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoucherLedger{db: db, chain: chain}, nil
+}
+
+//Close closes the underlying store
+func (l *VoucherLedger) Close() error {
+	return l.db.Close()
+}
+
+//SignVoucher signs a voucher of value for recipient with the next nonce and records it;
+//it refuses when value is more than the channel balance minus the unredeemed vouchers
+func (l *VoucherLedger) SignVoucher(channelID string, signer contracts.Signer, recipient common.Address, value *big.Int) (*Voucher, error) {
+	if value == nil || value.Sign() <= 0 {
+		return nil, ErrVoucherValue
+	}
+
+	channelAddr, err := address.GetAddressFromID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance *big.Int
+	if l.chain != nil {
+		//余额已扣除兑付过的凭证，先对账再读余额
+		err = l.Reconcile(channelAddr)
+		if err != nil {
+			return nil, err
+		}
+		balance, err = l.chain.GetChannelBalance(channelAddr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	if balance != nil {
+		liability, err := l.liability(channelAddr)
+		if err != nil {
+			return nil, err
+		}
+		if liability.Add(liability, value).Cmp(balance) > 0 {
+			return nil, ErrVoucherOverdraw
+		}
+	}
+
+	nonce := big.NewInt(1)
+	latest, err := l.latest(channelAddr, recipient)
+	if err == nil {
+		nonce.Add(latest.Nonce, nonce)
+	} else if err != ErrNoVoucher {
+		return nil, err
+	}
+
+	mes, err := SignForChannelPayWithSigner(channelID, signer, recipient, value, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Voucher{
+		Channel:   channelAddr,
+		Recipient: recipient,
+		Value:     value,
+		Nonce:     nonce,
+		Sign:      mes,
+	}
+	err = l.record(v, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+//Record records a voucher signed elsewhere, its nonce must be larger than the last one of the same recipient
+func (l *VoucherLedger) Record(v *Voucher) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	latest, err := l.latest(v.Channel, v.Recipient)
+	if err != nil && err != ErrNoVoucher {
+		return err
+	}
+
+	return l.record(v, latest)
+}
+
+//record checks v against latest and writes it
+func (l *VoucherLedger) record(v, latest *Voucher) error {
+	if v.Value == nil || v.Value.Sign() <= 0 {
+		return ErrVoucherValue
+	}
+	if v.Nonce == nil || v.Nonce.Sign() <= 0 || (latest != nil && v.Nonce.Cmp(latest.Nonce) <= 0) {
+		return ErrVoucherNonce
+	}
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put(voucherKey(v.Channel, v.Recipient, v.Nonce), val)
+	batch.Put(latestKey(v.Channel, v.Recipient), v.Nonce.Bytes())
+	return l.db.Write(batch, nil)
+}
+
+//Latest returns the last voucher signed for recipient on the channel
+func (l *VoucherLedger) Latest(channelAddr, recipient common.Address) (*Voucher, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	return l.latest(channelAddr, recipient)
+}
+
+func (l *VoucherLedger) latest(channelAddr, recipient common.Address) (*Voucher, error) {
+	nonce, err := l.db.Get(latestKey(channelAddr, recipient), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoVoucher
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return l.get(channelAddr, recipient, new(big.Int).SetBytes(nonce))
+}
+
+func (l *VoucherLedger) get(channelAddr, recipient common.Address, nonce *big.Int) (*Voucher, error) {
+	val, err := l.db.Get(voucherKey(channelAddr, recipient, nonce), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoVoucher
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(Voucher)
+	err = json.Unmarshal(val, v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+//Vouchers returns all vouchers of the channel, ordered by recipient and nonce
+func (l *VoucherLedger) Vouchers(channelAddr common.Address) ([]*Voucher, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	return l.vouchers(channelAddr)
+}
+
+func (l *VoucherLedger) vouchers(channelAddr common.Address) ([]*Voucher, error) {
+	var vs []*Voucher
+	iter := l.db.NewIterator(util.BytesPrefix([]byte(voucherPrefix+channelAddr.Hex()+"/")), nil)
+	defer iter.Release()
+	for iter.Next() {
+		v := new(Voucher)
+		err := json.Unmarshal(iter.Value(), v)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+
+	return vs, iter.Error()
+}
+
+//Reconcile marks the vouchers of the channel whose nonce the channel-contract has used as redeemed
+func (l *VoucherLedger) Reconcile(channelAddr common.Address) error {
+	if l.chain == nil {
+		return nil
+	}
+
+	vs, err := l.Vouchers(channelAddr)
+	if err != nil {
+		return err
+	}
+	for _, v := range vs {
+		if v.Redeemed {
+			continue
+		}
+		used, err := l.chain.GetNonceValue(channelAddr, v.Recipient, v.Nonce)
+		if err != nil {
+			return err
+		}
+		if !used {
+			continue
+		}
+		err = l.MarkRedeemed(channelAddr, v.Recipient, v.Nonce)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//MarkRedeemed marks the voucher as paid by the channel-contract
+func (l *VoucherLedger) MarkRedeemed(channelAddr, recipient common.Address, nonce *big.Int) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	v, err := l.get(channelAddr, recipient, nonce)
+	if err != nil {
+		return err
+	}
+	v.Redeemed = true
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return l.db.Put(voucherKey(channelAddr, recipient, nonce), val, nil)
+}
+
+//Liability returns the value of the channel's vouchers which are not redeemed yet
+func (l *VoucherLedger) Liability(channelAddr common.Address) (*big.Int, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	return l.liability(channelAddr)
+}
+
+func (l *VoucherLedger) liability(channelAddr common.Address) (*big.Int, error) {
+	vs, err := l.vouchers(channelAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := new(big.Int)
+	for _, v := range vs {
+		if !v.Redeemed {
+			sum.Add(sum, v.Value)
+		}
+	}
+	return sum, nil
+}
+
+//voucherKey nonce is left padded so that vouchers of a recipient iterate in nonce order
+func voucherKey(channelAddr, recipient common.Address, nonce *big.Int) []byte {
+	key := []byte(voucherPrefix + channelAddr.Hex() + "/" + recipient.Hex() + "/")
+	return append(key, common.LeftPadBytes(nonce.Bytes(), 32)...)
+}
+
+func latestKey(channelAddr, recipient common.Address) []byte {
+	return []byte(latestPrefix + channelAddr.Hex() + "/" + recipient.Hex())
+}
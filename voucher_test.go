@@ -0,0 +1,158 @@
+package role
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+func TestVoucherLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "voucher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := OpenVoucherLedger(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channelAddr := common.HexToAddress("0x1")
+	provider1 := common.HexToAddress("0x2")
+	provider2 := common.HexToAddress("0x3")
+
+	for _, v := range []*Voucher{
+		{Channel: channelAddr, Recipient: provider1, Value: big.NewInt(10), Nonce: big.NewInt(1)},
+		{Channel: channelAddr, Recipient: provider1, Value: big.NewInt(20), Nonce: big.NewInt(2)},
+		{Channel: channelAddr, Recipient: provider2, Value: big.NewInt(5), Nonce: big.NewInt(1)},
+	} {
+		err = l.Record(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = l.Record(&Voucher{Channel: channelAddr, Recipient: provider1, Value: big.NewInt(30), Nonce: big.NewInt(2)})
+	if err != ErrVoucherNonce {
+		t.Fatal("duplicate nonce is recorded:", err)
+	}
+	err = l.Record(&Voucher{Channel: channelAddr, Recipient: provider1, Value: big.NewInt(0), Nonce: big.NewInt(3)})
+	if err != ErrVoucherValue {
+		t.Fatal("zero value is recorded:", err)
+	}
+
+	err = l.MarkRedeemed(channelAddr, provider1, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// everything survives a restart
+	err = l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err = OpenVoucherLedger(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	latest, err := l.Latest(channelAddr, provider1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Nonce.Int64() != 2 || latest.Value.Int64() != 20 {
+		t.Fatal("wrong latest voucher", latest.Nonce, latest.Value)
+	}
+
+	_, err = l.Latest(channelAddr, common.HexToAddress("0x4"))
+	if err != ErrNoVoucher {
+		t.Fatal("unknown recipient has a voucher:", err)
+	}
+
+	liability, err := l.Liability(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liability.Int64() != 25 {
+		t.Fatal("wrong liability", liability)
+	}
+}
+
+func TestSignVoucherOverdraw(t *testing.T) {
+	dir, err := ioutil.TempDir("", "voucher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := contracts.NewECDSASigner(sk)
+
+	channelAddr := common.HexToAddress("0x1")
+	provider := common.HexToAddress("0x2")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{balance: big.NewInt(50)}
+	l, err := OpenVoucherLedger(dir, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, value := range []*big.Int{nil, big.NewInt(0), big.NewInt(-10)} {
+		_, err = l.SignVoucher(channelID, signer, provider, value)
+		if err != ErrVoucherValue {
+			t.Fatalf("voucher of value %v is signed: %v", value, err)
+		}
+	}
+
+	_, err = l.SignVoucher(channelID, signer, provider, big.NewInt(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.SignVoucher(channelID, signer, provider, big.NewInt(30))
+	if err != ErrVoucherOverdraw {
+		t.Fatal("voucher beyond the balance is signed:", err)
+	}
+	v, err := l.SignVoucher(channelID, signer, provider, big.NewInt(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// redeemed vouchers are paid out of the balance already
+	err = l.MarkRedeemed(channelAddr, provider, v.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.balance = big.NewInt(30)
+	_, err = l.SignVoucher(channelID, signer, provider, big.NewInt(1))
+	if err != ErrVoucherOverdraw {
+		t.Fatal("voucher beyond the balance is signed:", err)
+	}
+
+	// the first voucher is redeemed on chain without the ledger knowing, then the channel is topped up
+	chain.used = map[string]bool{provider.Hex() + "/1": true}
+	chain.balance = big.NewInt(10)
+	_, err = l.SignVoucher(channelID, signer, provider, big.NewInt(10))
+	if err != nil {
+		t.Fatal("redeemed voucher is still a liability:", err)
+	}
+	liability, err := l.Liability(channelAddr)
+	if err != nil || liability.Int64() != 10 {
+		t.Fatal("wrong liability", liability, err)
+	}
+}
@@ -0,0 +1,278 @@
+package role
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gogo/protobuf/proto"
+	"github.com/memoio/go-mefs/contracts"
+	mpb "github.com/memoio/go-mefs/pb"
+	"github.com/memoio/go-mefs/utils/address"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//errors of VoucherWallet.Receive
+var (
+	ErrVoucherRecipient   = errors.New("voucher is not for this provider")
+	ErrVoucherSign        = errors.New("voucher signature is invalid")
+	ErrVoucherSender      = errors.New("voucher is not signed by the channel sender")
+	ErrVoucherOverBalance = errors.New("voucher value exceeds the channel balance")
+)
+
+//ChannelBalance reads the money left in a channel-contract and which vouchers it has paid,
+//by nonce and by cumulative total, met by *contracts.ChannelNodeInfo
+type ChannelBalance interface {
+	GetChannelBalance(channelAddr common.Address) (*big.Int, error)
+	GetNonceValue(channelAddr, recipient common.Address, nonce *big.Int) (bool, error)
+	GetWithdrawn(channelAddr, recipient common.Address) (*big.Int, error)
+}
+
+//ChannelChain chain reads VoucherWallet needs to check vouchers and RedeemScheduler to follow the channels,
+//met by *contracts.ChannelNodeInfo
+type ChannelChain interface {
+	ChannelBalance
+	GetChannelInfo(channelAddr common.Address) (int64, int64, common.Address, []common.Address, error)
+	GetChannelState(channelAddr common.Address) (*contracts.ChannelState, error)
+}
+
+//VoucherWallet provider-side store of the vouchers received, kept on disk until they are redeemed
+type VoucherWallet struct {
+	lk    sync.Mutex
+	db    *leveldb.DB
+	addr  common.Address //the provider, recipient of all vouchers kept
+	chain ChannelChain
+}
+
+//OpenVoucherWallet opens or creates the wallet of provider addr stored in dir
+func OpenVoucherWallet(dir string, addr common.Address, chain ChannelChain) (*VoucherWallet, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoucherWallet{
+		db:    db,
+		addr:  addr,
+		chain: chain,
+	}, nil
+}
+
+//Close closes the underlying store
+func (w *VoucherWallet) Close() error {
+	return w.db.Close()
+}
+
+//Receive validates a marshaled mpb.ChannelSign sent by the payer and keeps it
+func (w *VoucherWallet) Receive(mes []byte) (*Voucher, error) {
+	cSign := new(mpb.ChannelSign)
+	err := proto.Unmarshal(mes, cSign)
+	if err != nil {
+		return nil, err
+	}
+
+	if common.BytesToAddress(cSign.GetRecipient()) != w.addr {
+		return nil, ErrVoucherRecipient
+	}
+	if len(cSign.GetSig()) < 64 || !VerifyChannelSign(cSign) {
+		return nil, ErrVoucherSign
+	}
+
+	channelAddr, err := address.GetAddressFromID(cSign.GetChannelID())
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.checkChannel(cSign, channelAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Voucher{
+		Channel:   channelAddr,
+		Recipient: w.addr,
+		Value:     new(big.Int).SetBytes(cSign.GetValue()),
+		Nonce:     new(big.Int).SetBytes(cSign.GetNonce()),
+		Sign:      mes,
+	}
+	if v.Value.Sign() <= 0 {
+		return nil, ErrVoucherValue
+	}
+
+	balance, err := w.chain.GetChannelBalance(channelAddr)
+	if err != nil {
+		return nil, err
+	}
+	if v.Value.Cmp(balance) > 0 {
+		return nil, ErrVoucherOverBalance
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	key := voucherKey(channelAddr, w.addr, v.Nonce)
+	has, err := w.db.Has(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return nil, ErrVoucherNonce
+	}
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = w.db.Put(key, val, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+//checkChannel checks that cSign is signed by the sender of the channel and the wallet is one of its recipients
+func (w *VoucherWallet) checkChannel(cSign *mpb.ChannelSign, channelAddr common.Address) error {
+	_, _, sender, recipients, err := w.chain.GetChannelInfo(channelAddr)
+	if err != nil {
+		return err
+	}
+	pub, err := crypto.DecompressPubkey(cSign.GetPubKey())
+	if err != nil || crypto.PubkeyToAddress(*pub) != sender {
+		return ErrVoucherSender
+	}
+	for _, recipient := range recipients {
+		if recipient == w.addr {
+			return nil
+		}
+	}
+	return ErrVoucherRecipient
+}
+
+//Vouchers returns the unredeemed vouchers of the channel, highest value first
+func (w *VoucherWallet) Vouchers(channelAddr common.Address) ([]*Voucher, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	var vs []*Voucher
+	iter := w.db.NewIterator(util.BytesPrefix([]byte(voucherPrefix+channelAddr.Hex()+"/")), nil)
+	defer iter.Release()
+	for iter.Next() {
+		v := new(Voucher)
+		err := json.Unmarshal(iter.Value(), v)
+		if err != nil {
+			return nil, err
+		}
+		if !v.Redeemed {
+			vs = append(vs, v)
+		}
+	}
+	if iter.Error() != nil {
+		return nil, iter.Error()
+	}
+
+	sort.SliceStable(vs, func(i, j int) bool {
+		return vs[i].Value.Cmp(vs[j].Value) > 0
+	})
+	return vs, nil
+}
+
+//Best returns the unredeemed voucher of the channel with the highest value
+func (w *VoucherWallet) Best(channelAddr common.Address) (*Voucher, error) {
+	vs, err := w.Vouchers(channelAddr)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs) == 0 {
+		return nil, ErrNoVoucher
+	}
+	return vs[0], nil
+}
+
+//Redeemable returns the vouchers of the channel which its current balance can pay, highest value first,
+//and the ones left over which the balance cannot pay any more
+func (w *VoucherWallet) Redeemable(channelAddr common.Address) ([]*Voucher, []*Voucher, error) {
+	vs, err := w.Vouchers(channelAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balance, err := w.chain.GetChannelBalance(channelAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res, short []*Voucher
+	for _, v := range vs {
+		if v.Value.Cmp(balance) > 0 {
+			short = append(short, v)
+			continue
+		}
+		balance = new(big.Int).Sub(balance, v.Value)
+		res = append(res, v)
+	}
+	return res, short, nil
+}
+
+//Channels returns the channels which have unredeemed vouchers, cumulative ones included
+func (w *VoucherWallet) Channels() ([]common.Address, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	var res []common.Address
+	seen := make(map[common.Address]struct{})
+	for _, prefix := range []string{voucherPrefix, totalPrefix} {
+		iter := w.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for iter.Next() {
+			v := new(Voucher)
+			err := json.Unmarshal(iter.Value(), v)
+			if err != nil {
+				iter.Release()
+				return nil, err
+			}
+			if _, ok := seen[v.Channel]; ok || v.Redeemed || v.Recipient != w.addr {
+				continue
+			}
+			seen[v.Channel] = struct{}{}
+			res = append(res, v.Channel)
+		}
+		iter.Release()
+		if iter.Error() != nil {
+			return nil, iter.Error()
+		}
+	}
+	return res, nil
+}
+
+//MarkRedeemed marks the voucher as paid by the channel-contract
+func (w *VoucherWallet) MarkRedeemed(channelAddr common.Address, nonce *big.Int) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	key := voucherKey(channelAddr, w.addr, nonce)
+	val, err := w.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return ErrNoVoucher
+	}
+	if err != nil {
+		return err
+	}
+
+	v := new(Voucher)
+	err = json.Unmarshal(val, v)
+	if err != nil {
+		return err
+	}
+	v.Redeemed = true
+
+	val, err = json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.db.Put(key, val, nil)
+}
@@ -0,0 +1,154 @@
+package role
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/memoio/go-mefs/contracts"
+	"github.com/memoio/go-mefs/utils/address"
+)
+
+type fakeChain struct {
+	sender     common.Address
+	recipients []common.Address
+	balance    *big.Int
+	withdrawn  map[common.Address]*big.Int
+	used       map[string]bool //recipient/nonce paid by the channel
+	destroyed  bool
+}
+
+func (c *fakeChain) GetChannelInfo(channelAddr common.Address) (int64, int64, common.Address, []common.Address, error) {
+	return 0, 3600, c.sender, c.recipients, nil
+}
+
+func (c *fakeChain) GetChannelState(channelAddr common.Address) (*contracts.ChannelState, error) {
+	state := &contracts.ChannelState{
+		Address:    channelAddr,
+		Sender:     c.sender,
+		Recipients: c.recipients,
+		Timeout:    3600 * time.Second,
+		Expiry:     time.Unix(3600, 0),
+		Balance:    c.balance,
+	}
+	if c.destroyed {
+		state.Status = contracts.ChannelDestroyed
+	}
+	return state, nil
+}
+
+func (c *fakeChain) GetChannelBalance(channelAddr common.Address) (*big.Int, error) {
+	return c.balance, nil
+}
+
+func (c *fakeChain) GetNonceValue(channelAddr, recipient common.Address, nonce *big.Int) (bool, error) {
+	return c.used[recipient.Hex()+"/"+nonce.String()], nil
+}
+
+func (c *fakeChain) GetWithdrawn(channelAddr, recipient common.Address) (*big.Int, error) {
+	if w, ok := c.withdrawn[recipient]; ok {
+		return w, nil
+	}
+	return new(big.Int), nil
+}
+
+func TestVoucherWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	payerSk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := contracts.NewECDSASigner(payerSk)
+	other := contracts.NewECDSASigner(otherSk)
+
+	provider := common.HexToAddress("0x2")
+	channelAddr := common.HexToAddress("0x1")
+	channelID, err := address.GetIDFromAddress(channelAddr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &fakeChain{
+		sender:     payer.Address(),
+		recipients: []common.Address{provider},
+		balance:    big.NewInt(100),
+	}
+	w, err := OpenVoucherWallet(dir, provider, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	sign := func(signer contracts.Signer, recipient common.Address, value, nonce int64) []byte {
+		mes, err := SignForChannelPayWithSigner(channelID, signer, recipient, big.NewInt(value), big.NewInt(nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mes
+	}
+
+	tests := []struct {
+		name string
+		mes  []byte
+		want error
+	}{
+		{"first", sign(payer, provider, 30, 1), nil},
+		{"second", sign(payer, provider, 60, 2), nil},
+		{"same nonce", sign(payer, provider, 10, 2), ErrVoucherNonce},
+		{"other recipient", sign(payer, common.HexToAddress("0x3"), 10, 3), ErrVoucherRecipient},
+		{"other signer", sign(other, provider, 10, 3), ErrVoucherSender},
+		{"over balance", sign(payer, provider, 101, 3), ErrVoucherOverBalance},
+	}
+	for _, test := range tests {
+		_, err := w.Receive(test.mes)
+		if err != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	best, err := w.Best(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Value.Int64() != 60 {
+		t.Fatal("wrong best voucher", best.Value)
+	}
+
+	// the balance only covers the best voucher now
+	chain.balance = big.NewInt(80)
+	vs, short, err := w.Redeemable(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1 || vs[0].Nonce.Int64() != 2 {
+		t.Fatal("wrong redeemable vouchers", vs)
+	}
+	if len(short) != 1 || short[0].Nonce.Int64() != 1 {
+		t.Fatal("wrong vouchers the balance cannot pay", short)
+	}
+
+	err = w.MarkRedeemed(channelAddr, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err = w.Best(channelAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Nonce.Int64() != 1 {
+		t.Fatal("redeemed voucher is still the best", best.Nonce)
+	}
+}
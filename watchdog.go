@@ -0,0 +1,346 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	defaultStuckBlocks      = 10               //blocks a transaction may stay pending before it is replaced
+	defaultWatchdogInterval = 15 * time.Second //time between two checks in Run
+	waitReceiptInterval     = time.Second      //time between two receipt queries in Wait
+)
+
+//ErrTxCancelled the transaction is replaced by a self-transfer through TxWatchdog.Cancel
+var ErrTxCancelled = errors.New("transaction is cancelled")
+
+//ErrTxNotTracked the transaction is not tracked by the watchdog
+var ErrTxNotTracked = errors.New("transaction is not tracked")
+
+type txKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+//trackedTx all versions sent for one nonce, the last one is the current
+type trackedTx struct {
+	name     string
+	signer   Signer
+	txSigner types.Signer //signs the replacements for the chain of the first version
+	versions []*types.Transaction
+	cancel   map[common.Hash]bool //versions which are cancel transactions
+	sentAt   uint64               //block number when the current version was sent
+
+	receipt *types.Receipt //receipt of the version mined, nil if none is
+	mined   common.Hash    //hash of the version mined
+	minedAt uint64         //block number when Check finds the receipt
+}
+
+func (t *trackedTx) current() *types.Transaction {
+	return t.versions[len(t.versions)-1]
+}
+
+//TxWatchdog tracks pending channel transactions and rebroadcasts those stuck for StuckBlocks with a higher gas price;
+//set it in TxPolicy.Watchdog so that SendTx tracks everything it sends
+type TxWatchdog struct {
+	lk      sync.Mutex
+	backend ChannelBackend
+	policy  *TxPolicy
+	txs     map[txKey]*trackedTx
+
+	StuckBlocks uint64        //blocks a transaction may stay pending before it is replaced
+	Interval    time.Duration //time between two checks in Run
+}
+
+//NewTxWatchdog new a watchdog sending replacements to backend, gas prices are bumped by policy
+func NewTxWatchdog(backend ChannelBackend, policy *TxPolicy) *TxWatchdog {
+	if policy == nil {
+		policy = DefaultTxPolicy()
+	}
+	return &TxWatchdog{
+		backend:     backend,
+		policy:      policy,
+		txs:         make(map[txKey]*trackedTx),
+		StuckBlocks: defaultStuckBlocks,
+		Interval:    defaultWatchdogInterval,
+	}
+}
+
+//Track starts tracking tx sent by signer, name is used in logs
+func (w *TxWatchdog) Track(ctx context.Context, name string, signer Signer, tx *types.Transaction) error {
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//替换交易与原交易在同一条链上签名
+	var txSigner types.Signer
+	if tx.Protected() {
+		txSigner = types.NewEIP155Signer(tx.ChainId())
+	} else {
+		txSigner, err = chainSigner(ctx, w.backend, w.policy)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	key := txKey{signer.Address(), tx.Nonce()}
+	t, ok := w.txs[key]
+	if !ok {
+		t = &trackedTx{
+			name:     name,
+			signer:   signer,
+			txSigner: txSigner,
+			cancel:   make(map[common.Hash]bool),
+		}
+		w.txs[key] = t
+	}
+	t.versions = append(t.versions, tx)
+	t.sentAt = head.Number.Uint64()
+	return nil
+}
+
+//Pending returns the current version of every transaction not mined yet
+func (w *TxWatchdog) Pending() []*types.Transaction {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	res := make([]*types.Transaction, 0, len(w.txs))
+	for _, t := range w.txs {
+		if t.receipt == nil {
+			res = append(res, t.current())
+		}
+	}
+	return res
+}
+
+//Run checks every Interval until ctx is done
+func (w *TxWatchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		_, err := w.Check(ctx)
+		if err != nil {
+			log.Println("check pending transactions fails:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Check replaces the transactions pending for StuckBlocks, returns the replacements sent;
+//transactions mined are dropped StuckBlocks later, so that Wait still finds their receipts
+func (w *TxWatchdog) Check(ctx context.Context) ([]*types.Transaction, error) {
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	headNum := head.Number.Uint64()
+
+	//持锁只复制状态，查询和发送交易时不持锁，以免阻塞Track、Wait和Pending
+	type pendingTx struct {
+		t        *trackedTx
+		versions []*types.Transaction
+		sentAt   uint64
+	}
+	var pending []pendingTx
+	w.lk.Lock()
+	for key, t := range w.txs {
+		if t.receipt != nil {
+			if headNum >= t.minedAt+w.StuckBlocks {
+				delete(w.txs, key)
+			}
+			continue
+		}
+		pending = append(pending, pendingTx{t, append([]*types.Transaction(nil), t.versions...), t.sentAt})
+	}
+	w.lk.Unlock()
+
+	var replaced []*types.Transaction
+	for _, p := range pending {
+		t := p.t
+		receipt, hash, err := w.receipt(ctx, p.versions)
+		if err != nil {
+			return replaced, err
+		}
+		if receipt != nil {
+			w.lk.Lock()
+			if t.receipt == nil {
+				t.receipt, t.mined, t.minedAt = receipt, hash, headNum
+			}
+			w.lk.Unlock()
+			continue
+		}
+
+		if headNum < p.sentAt+w.StuckBlocks {
+			continue
+		}
+
+		cur := p.versions[len(p.versions)-1]
+		price, err := bumpGasPrice(cur.GasPrice(), w.policy)
+		if err != nil {
+			log.Println(t.name, "transaction", cur.Hash().Hex(), "is stuck:", err)
+			continue
+		}
+
+		var tx *types.Transaction
+		if cur.To() == nil {
+			tx = types.NewContractCreation(cur.Nonce(), cur.Value(), cur.Gas(), price, cur.Data())
+		} else {
+			tx = types.NewTransaction(cur.Nonce(), *cur.To(), cur.Value(), cur.Gas(), price, cur.Data())
+		}
+		tx, err = w.send(ctx, t, tx)
+		if err != nil {
+			log.Println("replace", t.name, "transaction", cur.Hash().Hex(), "fails:", err)
+			continue
+		}
+
+		w.lk.Lock()
+		t.versions = append(t.versions, tx)
+		if t.cancel[cur.Hash()] {
+			t.cancel[tx.Hash()] = true
+		}
+		t.sentAt = headNum
+		w.lk.Unlock()
+
+		log.Println("replace stuck", t.name, "transaction", cur.Hash().Hex(), "by", tx.Hash().Hex(), "with gasPrice", price)
+		replaced = append(replaced, tx)
+	}
+
+	return replaced, nil
+}
+
+//Cancel replaces the pending transaction of from with the nonce by a zero-value transfer to itself
+func (w *TxWatchdog) Cancel(ctx context.Context, from common.Address, nonce uint64) (*types.Transaction, error) {
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	w.lk.Lock()
+	t, ok := w.txs[txKey{from, nonce}]
+	if !ok || t.receipt != nil {
+		w.lk.Unlock()
+		return nil, ErrTxNotTracked
+	}
+	price, err := bumpGasPrice(t.current().GasPrice(), w.policy)
+	w.lk.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	//同nonce向自己转账0
+	tx, err := w.send(ctx, t, types.NewTransaction(nonce, from, new(big.Int), params.TxGas, price, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	w.lk.Lock()
+	t.versions = append(t.versions, tx)
+	t.cancel[tx.Hash()] = true
+	t.sentAt = head.Number.Uint64()
+	w.lk.Unlock()
+
+	log.Println("cancel", t.name, "transaction of nonce", nonce, "by", tx.Hash().Hex())
+	return tx, nil
+}
+
+//Wait waits until one version of tx sent by from is mined, for at most the MineTimeout of the policy if it is not 0;
+//a reverted one gives ErrTxFail, a cancel one ErrTxCancelled, none mined in time ErrTxNotMined
+func (w *TxWatchdog) Wait(ctx context.Context, from common.Address, tx *types.Transaction) (*types.Receipt, error) {
+	var deadline <-chan time.Time
+	if w.policy.MineTimeout > 0 {
+		t := time.NewTimer(w.policy.MineTimeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+
+	key := txKey{from, tx.Nonce()}
+	for {
+		versions := []*types.Transaction{tx}
+		var cancel map[common.Hash]bool
+		var receipt *types.Receipt
+		var hash common.Hash
+
+		w.lk.Lock()
+		t, ok := w.txs[key]
+		if ok {
+			versions = append([]*types.Transaction(nil), t.versions...)
+			cancel = make(map[common.Hash]bool, len(t.cancel))
+			for h := range t.cancel {
+				cancel[h] = true
+			}
+			receipt, hash = t.receipt, t.mined
+		}
+		w.lk.Unlock()
+
+		if receipt == nil {
+			var err error
+			receipt, hash, err = w.receipt(ctx, versions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if receipt != nil {
+			if cancel[hash] {
+				return receipt, ErrTxCancelled
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return receipt, ErrTxFail
+			}
+			return receipt, nil
+		}
+
+		wait := time.NewTimer(waitReceiptInterval)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return nil, ctx.Err()
+		case <-deadline:
+			wait.Stop()
+			return nil, ErrTxNotMined
+		case <-wait.C:
+		}
+	}
+}
+
+//receipt returns the receipt of the version which is mined and its hash, nil if none is
+func (w *TxWatchdog) receipt(ctx context.Context, versions []*types.Transaction) (*types.Receipt, common.Hash, error) {
+	hashes := make([]common.Hash, len(versions))
+	for i, v := range versions {
+		hashes[i] = v.Hash()
+	}
+	receipt, err := minedReceipt(ctx, w.backend, hashes)
+	if err != nil || receipt == nil {
+		return nil, common.Hash{}, err
+	}
+	return receipt, receipt.TxHash, nil
+}
+
+//send signs tx by the signer of t and sends it, the caller adds it as the current version of t under w.lk
+func (w *TxWatchdog) send(ctx context.Context, t *trackedTx, tx *types.Transaction) (*types.Transaction, error) {
+	signed, err := t.signer.SignTx(t.txSigner, tx)
+	if err != nil {
+		return nil, err
+	}
+	err = w.backend.SendTransaction(ctx, signed)
+	if err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
@@ -0,0 +1,161 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTxWatchdog(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewECDSASigner(sk)
+	from := signer.Address()
+	to := common.HexToAddress("0x2000")
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from: {Balance: big.NewInt(1000000000000000000)},
+	}, 100000000)
+	defer sim.Close()
+	ctx := context.Background()
+	// the chain ID of the simulated backend
+	chainSigner := types.NewEIP155Signer(big.NewInt(1337))
+
+	wd := NewTxWatchdog(sim, &TxPolicy{GasBump: big.NewInt(1)})
+	wd.StuckBlocks = 2
+
+	// signed but never reaches the node, so it stays pending
+	stuck := func(nonce uint64) *types.Transaction {
+		tx, err := signer.SignTx(chainSigner, types.NewTransaction(nonce, to, big.NewInt(1), 21000, big.NewInt(1), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = wd.Track(ctx, "test", signer, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	tx := stuck(0)
+	replaced, err := wd.Check(ctx)
+	if err != nil || len(replaced) != 0 {
+		t.Fatal("replaced before it is stuck:", replaced, err)
+	}
+	sim.Commit()
+	sim.Commit()
+	replaced, err = wd.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replaced) != 1 || replaced[0].Nonce() != 0 || replaced[0].GasPrice().Int64() != 2 {
+		t.Fatal("stuck transaction is not replaced:", replaced)
+	}
+	if !replaced[0].Protected() || replaced[0].ChainId().Int64() != 1337 {
+		t.Fatal("replacement is not signed for the chain of the original")
+	}
+	sim.Commit()
+	receipt, err := wd.Wait(ctx, from, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != replaced[0].Hash() {
+		t.Fatal("receipt is not of the replacement")
+	}
+
+	tx = stuck(1)
+	cancel, err := wd.Cancel(ctx, from, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cancel.To() != from || cancel.Value().Sign() != 0 {
+		t.Fatal("cancel is not a zero-value self-transfer")
+	}
+	if !cancel.Protected() || cancel.ChainId().Int64() != 1337 {
+		t.Fatal("cancel is not signed for the chain of the original")
+	}
+	sim.Commit()
+	_, err = wd.Wait(ctx, from, tx)
+	if err != ErrTxCancelled {
+		t.Fatal("cancelled transaction is not reported:", err)
+	}
+
+	_, err = wd.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wd.Pending()) != 0 {
+		t.Fatal("mined transactions are still pending")
+	}
+	_, err = wd.Cancel(ctx, from, 5)
+	if err != ErrTxNotTracked {
+		t.Fatal("cancels an unknown transaction:", err)
+	}
+}
+
+// slowBackend holds every receipt query until release is closed
+type slowBackend struct {
+	*backends.SimulatedBackend
+	queried chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	select {
+	case b.queried <- struct{}{}:
+	default:
+	}
+	<-b.release
+	return b.SimulatedBackend.TransactionReceipt(ctx, hash)
+}
+
+func TestTxWatchdogWait(t *testing.T) {
+	sk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewECDSASigner(sk)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		signer.Address(): {Balance: big.NewInt(1000000000000000000)},
+	}, 100000000)
+	defer sim.Close()
+	backend := &slowBackend{SimulatedBackend: sim, queried: make(chan struct{}, 1), release: make(chan struct{})}
+	ctx := context.Background()
+
+	wd := NewTxWatchdog(backend, &TxPolicy{GasBump: big.NewInt(1), MineTimeout: 50 * time.Millisecond})
+	tx, err := signer.SignTx(types.NewEIP155Signer(big.NewInt(1337)), types.NewTransaction(0, common.HexToAddress("0x2000"), big.NewInt(1), 21000, big.NewInt(1), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wd.Track(ctx, "test", signer, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the receipt queries of Check do not hold the lock
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wd.Check(ctx)
+	}()
+	<-backend.queried
+	if len(wd.Pending()) != 1 {
+		t.Fatal("pending transaction is lost")
+	}
+	close(backend.release)
+	<-done
+
+	// nobody replaces it, Wait gives up after MineTimeout
+	_, err = wd.Wait(ctx, signer.Address(), tx)
+	if err != ErrTxNotMined {
+		t.Fatalf("got %v, want %v", err, ErrTxNotMined)
+	}
+}